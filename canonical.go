@@ -0,0 +1,235 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gjson
+
+import (
+	"crypto/sha256"
+	"hash/fnv"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Canonical returns the RFC 8785 JSON Canonicalization Scheme (JCS) form of
+// the json. Object members are sorted by key, insignificant whitespace is
+// removed, strings use minimal escaping, and numbers are formatted as in
+// ECMAScript.
+//
+//	{"b": 1.50, "a": "A"}  ->  {"a":"A","b":1.5}
+//
+// For duplicate object keys, the first member is used, as with Get. Numbers
+// that are too large for a float64 are written as null, which matches
+// JSON.stringify in ECMAScript. An empty string is returned when the json is
+// not valid.
+func Canonical(json string) string {
+	if !Valid(json) {
+		return ""
+	}
+	var e canonicalEncoder
+	e.value(Parse(json))
+	return string(e.buf)
+}
+
+// Hash returns the 64-bit FNV-1a hash of the canonical form of the value, as
+// returned by Canonical. Equal values, as reported by Equal, have the same
+// hash. The canonical form is streamed to the hash function without being
+// stored.
+func (t Result) Hash() uint64 {
+	h := fnv.New64a()
+	e := canonicalEncoder{w: h}
+	e.value(t)
+	e.flush()
+	return h.Sum64()
+}
+
+// Sum256 returns the SHA-256 checksum of the canonical form of the value, as
+// returned by Canonical. The canonical form is streamed to the hash function
+// without being stored.
+func (t Result) Sum256() [32]byte {
+	h := sha256.New()
+	e := canonicalEncoder{w: h}
+	e.value(t)
+	e.flush()
+	var sum [32]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// @canonical converts the json to the RFC 8785 canonical form.
+//
+//	{"b": 1.50, "a": "A"}  ->  {"a":"A","b":1.5}
+func modCanonical(json, arg string) string {
+	return Canonical(json)
+}
+
+// canonicalEncoder writes the canonical form of a value to buf, which is
+// flushed to w when w is not nil.
+type canonicalEncoder struct {
+	w   io.Writer
+	buf []byte
+}
+
+func (e *canonicalEncoder) flush() {
+	if e.w != nil {
+		e.w.Write(e.buf)
+		e.buf = e.buf[:0]
+	}
+}
+
+func (e *canonicalEncoder) value(t Result) {
+	if e.w != nil && len(e.buf) > 4096 {
+		e.flush()
+	}
+	switch t.Type {
+	case Null:
+		if t.Exists() {
+			e.buf = append(e.buf, "null"...)
+		}
+	case False:
+		e.buf = append(e.buf, "false"...)
+	case True:
+		e.buf = append(e.buf, "true"...)
+	case Number:
+		e.buf = appendCanonicalNumber(e.buf, t.Num)
+	case String:
+		e.buf = appendCanonicalString(e.buf, t.Str)
+	case JSON:
+		if t.IsArray() {
+			e.buf = append(e.buf, '[')
+			var i int
+			t.ForEach(func(_, value Result) bool {
+				if i > 0 {
+					e.buf = append(e.buf, ',')
+				}
+				e.value(value)
+				i++
+				return true
+			})
+			e.buf = append(e.buf, ']')
+		} else {
+			kvs := sortedMembers(t)
+			sort.Stable(byUTF16Key(kvs))
+			e.buf = append(e.buf, '{')
+			for i := 0; i < len(kvs); i += 2 {
+				if i > 0 {
+					e.buf = append(e.buf, ',')
+				}
+				e.buf = appendCanonicalString(e.buf, kvs[i].Str)
+				e.buf = append(e.buf, ':')
+				e.value(kvs[i+1])
+			}
+			e.buf = append(e.buf, '}')
+		}
+	}
+}
+
+// byUTF16Key sorts a slice of alternating keys and values by the UTF-16 code
+// units of the keys, as required by RFC 8785.
+type byUTF16Key []Result
+
+func (kvs byUTF16Key) Len() int {
+	return len(kvs) / 2
+}
+
+func (kvs byUTF16Key) Less(i, j int) bool {
+	return lessUTF16(kvs[i*2].Str, kvs[j*2].Str)
+}
+
+func (kvs byUTF16Key) Swap(i, j int) {
+	byKey(kvs).Swap(i, j)
+}
+
+// lessUTF16 compares two strings by their UTF-16 code units. This differs
+// from a byte comparison only for characters above U+FFFF, which sort as
+// surrogate pairs before U+E000 through U+FFFF.
+func lessUTF16(a, b string) bool {
+	for len(a) > 0 && len(b) > 0 {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if ra != rb {
+			return utf16Unit(ra) < utf16Unit(rb)
+		}
+		a, b = a[na:], b[nb:]
+	}
+	return len(a) < len(b)
+}
+
+// utf16Unit returns the first UTF-16 code unit of a rune.
+func utf16Unit(r rune) rune {
+	if r >= 0x10000 {
+		return 0xD800 + (r-0x10000)>>10
+	}
+	return r
+}
+
+// appendCanonicalNumber appends a number using the ECMAScript
+// Number.prototype.toString format.
+func appendCanonicalNumber(dst []byte, f float64) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return append(dst, "null"...)
+	}
+	if f == 0 {
+		// includes negative zero
+		return append(dst, '0')
+	}
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs < 1e-6 || abs >= 1e21 {
+		format = 'e'
+	}
+	dst = strconv.AppendFloat(dst, f, format, -1, 64)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(dst)
+		if n >= 4 && dst[n-4] == 'e' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+	return dst
+}
+
+// appendCanonicalString appends a json string with the minimal escaping
+// required by RFC 8785. Invalid UTF-8 is replaced with U+FFFD.
+func appendCanonicalString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			dst = append(dst, '\\', c)
+		case c < ' ':
+			dst = append(dst, '\\')
+			switch c {
+			case '\b':
+				dst = append(dst, 'b')
+			case '\f':
+				dst = append(dst, 'f')
+			case '\n':
+				dst = append(dst, 'n')
+			case '\r':
+				dst = append(dst, 'r')
+			case '\t':
+				dst = append(dst, 't')
+			default:
+				dst = append(dst, 'u')
+				dst = appendHex16(dst, uint16(c))
+			}
+		case c < utf8.RuneSelf:
+			dst = append(dst, c)
+		default:
+			r, n := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && n == 1 {
+				dst = append(dst, "�"...)
+			} else {
+				dst = append(dst, s[i:i+n]...)
+			}
+			i += n - 1
+		}
+	}
+	return append(dst, '"')
+}
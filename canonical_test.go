@@ -0,0 +1,89 @@
+package gjson
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	// RFC 8785, Section 3.2.2
+	json := `{
+		"numbers": [333333333.33333329, 1E30, 4.50, 2e-3,
+			0.000000000000000000000000001],
+		"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+		"literals": [null, true, false]
+	}`
+	assert(t, Canonical(json) == `{"literals":[null,true,false],`+
+		`"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],`+
+		`"string":"€$\u000f\nA'B\"\\\\\"/"}`)
+	// RFC 8785, Section 3.2.3
+	json = `{
+		"€": "Euro Sign",
+		"\r": "Carriage Return",
+		"דּ": "Hebrew Letter Dalet With Dagesh",
+		"1": "One",
+		"😀": "Emoji: Grinning Face",
+		"\u0080": "Control",
+		"ö": "Latin Small Letter O With Diaeresis"
+	}`
+	var keys []string
+	Parse(Canonical(json)).ForEach(func(key, _ Result) bool {
+		keys = append(keys, key.Str)
+		return true
+	})
+	assert(t, len(keys) == 7 && keys[0] == "\r" && keys[1] == "1" &&
+		keys[2] == "\u0080" && keys[3] == "ö" && keys[4] == "€" &&
+		keys[5] == "\U0001F600" && keys[6] == "דּ")
+	assert(t, Canonical(`{"a":1,"a":2}`) == `{"a":1}`)
+	assert(t, Canonical(`[1e400]`) == `[null]`)
+	assert(t, Canonical(`{"a":`) == "")
+	assert(t, Canonical(` "<&> " `) == "\"<&> \"")
+}
+
+func TestCanonicalNumbers(t *testing.T) {
+	// RFC 8785, Appendix B
+	for _, tc := range []struct{ in, out string }{
+		{"0", "0"},
+		{"-0", "0"},
+		{"5e-324", "5e-324"},
+		{"-5e-324", "-5e-324"},
+		{"1.7976931348623157e308", "1.7976931348623157e+308"},
+		{"9007199254740992", "9007199254740992"},
+		{"-9007199254740992", "-9007199254740992"},
+		{"295147905179352830000", "295147905179352830000"},
+		{"9.999999999999997e22", "9.999999999999997e+22"},
+		{"1e21", "1e+21"},
+		{"999999999999999700000", "999999999999999700000"},
+		{"0.000001", "0.000001"},
+		{"1e-7", "1e-7"},
+		{"333333333.3333332", "333333333.3333332"},
+		{"0.3", "0.3"},
+		{"1.0", "1"},
+	} {
+		if out := Canonical(tc.in); out != tc.out {
+			t.Fatalf("%s: expected %s, got %s", tc.in, tc.out, out)
+		}
+	}
+}
+
+func TestCanonicalHash(t *testing.T) {
+	a := Parse(`{"b":[1.0,"x"],"a":{"z":null,"y":true}}`)
+	b := Parse(`{ "a" : { "y" : true, "z" : null }, "b" : [ 1, "x" ] }`)
+	c := Parse(`{"b":[1,"x"],"a":{"z":null,"y":false}}`)
+	assert(t, a.Equal(b) && a.Hash() == b.Hash())
+	assert(t, a.Hash() != c.Hash())
+	sum := a.Sum256()
+	assert(t, sum == b.Sum256() && sum != c.Sum256())
+	assert(t, sum == sha256.Sum256([]byte(Canonical(a.Raw))))
+	// large enough to be flushed in parts
+	big := "["
+	for i := 0; i < 2000; i++ {
+		if i > 0 {
+			big += ","
+		}
+		big += `{"n":1,"s":"text"}`
+	}
+	big += "]"
+	assert(t, Parse(big).Sum256() == sha256.Sum256([]byte(Canonical(big))))
+	assert(t, Get(`{"a":{"b":2,"a":1}}`, "a.@canonical").Raw == `{"a":1,"b":2}`)
+}
@@ -0,0 +1,322 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gjson
+
+import (
+	"sort"
+	"strings"
+)
+
+// isDescent reports whether the path starts with a recursive descent, which
+// is ".." followed by a key, such as "..name".
+func isDescent(path string) bool {
+	return len(path) > 2 && path[0] == '.' && path[1] == '.' &&
+		isDescentChar(path[2])
+}
+
+// afterSep returns the path after the separator at path[i]. A '.' followed
+// by a recursive descent is kept, so that "a..b" is "a" and "..b".
+func afterSep(path string, i int) string {
+	if isDescent(path[i:]) {
+		return path[i:]
+	}
+	return path[i+1:]
+}
+
+// isDescentChar reports whether a key of a recursive descent may start with
+// the character. The ".." prefix followed by anything else, such as "..#" or
+// "..0", is JSON Lines.
+func isDescentChar(c byte) bool {
+	switch c {
+	case '.', '|', '#', '@', '[', '{', '!':
+		return false
+	}
+	return c < '0' || c > '9'
+}
+
+// descentMatch is a value found by a descender.
+type descentMatch struct {
+	node       int // the container of the value, in pre-order
+	start, end int
+	depth      int
+}
+
+// descender finds the members with a key at any depth of json in a single
+// pass, or every value when all is set.
+type descender struct {
+	json  string
+	lim   *limiter
+	all   bool
+	part  string
+	fpart string
+	wild  bool
+	fold  bool
+	nodes int
+	found []descentMatch
+}
+
+func (d *descender) match(key string) bool {
+	if d.wild {
+		return matchLimit(key, d.part) ||
+			(d.fold && matchLimit(foldString(key), d.fpart))
+	}
+	return key == d.part || (d.fold && strings.EqualFold(key, d.part))
+}
+
+// value skips the value at json[i], visiting the members and elements of
+// an object or an array, and returns the position after it.
+func (d *descender) value(i, depth int) int {
+	switch d.json[i] {
+	case '{', '[':
+		return d.container(i, depth)
+	}
+	start := i
+	if d.json[i] == '"' {
+		i, _, _, _ = parseString(d.json, i+1)
+	} else {
+		i, _ = parseNumber(d.json, i)
+	}
+	if d.all {
+		d.found = append(d.found, descentMatch{-1, start, i, depth})
+	}
+	return i
+}
+
+func (d *descender) container(i, depth int) int {
+	if d.lim != nil && !d.lim.nested(depth+1) {
+		return len(d.json)
+	}
+	node := d.nodes
+	d.nodes++
+	slot := -1
+	if d.all {
+		slot = len(d.found)
+		d.found = append(d.found, descentMatch{node, i, 0, depth})
+	}
+	obj := d.json[i] == '{'
+	for i++; i < len(d.json); {
+		switch d.json[i] {
+		case ' ', '\t', '\n', '\r', ',', ':':
+			i++
+			continue
+		case '/':
+			i = skipComment(d.json, i)
+			continue
+		case '}', ']':
+			i++
+			if slot >= 0 {
+				d.found[slot].end = i
+			}
+			return i
+		}
+		if d.lim != nil && !d.lim.step(1) {
+			return len(d.json)
+		}
+		matched := false
+		if obj {
+			if d.json[i] != '"' {
+				// not a key
+				i++
+				continue
+			}
+			var key string
+			var esc bool
+			i, key, esc, _ = parseString(d.json, i+1)
+			if len(key) > 1 {
+				key = key[1 : len(key)-1]
+			}
+			if esc {
+				key = unescape(key)
+			}
+			for i < len(d.json) && (d.json[i] <= ' ' || d.json[i] == ':' ||
+				d.json[i] == '/') {
+				if d.json[i] == '/' {
+					i = skipComment(d.json, i)
+				} else {
+					i++
+				}
+			}
+			if i == len(d.json) {
+				break
+			}
+			matched = !d.all && d.match(key)
+		}
+		start := i
+		i = d.value(i, depth+1)
+		if matched {
+			d.found = append(d.found, descentMatch{node, start, i, depth + 1})
+		}
+	}
+	if slot >= 0 {
+		d.found[slot].end = len(d.json)
+	}
+	return len(d.json)
+}
+
+// walk finds the values of json. A member is found after the members of the
+// object that it's in, and before the members of the objects in it, like
+// the values of a search that starts over at each object. Each top-level
+// value is walked in turn, so that every line of JSON Lines is searched.
+func (d *descender) walk() []descentMatch {
+	for i := 0; i < len(d.json); {
+		switch d.json[i] {
+		case ' ', '\t', '\n', '\r':
+			i++
+			continue
+		case '/':
+			i = skipComment(d.json, i)
+			continue
+		case '{', '[':
+			i = d.container(i, 0)
+			continue
+		}
+		// a scalar has no members
+		j, _, ok := parseAny(d.json, i, false)
+		if !ok {
+			break
+		}
+		if d.all {
+			d.found = append(d.found, descentMatch{-1, i, j, 0})
+		}
+		i = j
+	}
+	if !d.all {
+		sort.SliceStable(d.found, func(i, j int) bool {
+			return d.found[i].node < d.found[j].node
+		})
+	}
+	return d.found
+}
+
+// getDescent returns an array of each member of json with a key at any
+// depth, from a path that's a key after a recursive descent, such as
+// "name" of "..name". The components after the key, up to the next pipe,
+// apply to each member, and when they return an array with a '#' or a
+// query, such as "..items.#.name", its elements are added instead.
+func getDescent(json, path string, l *limiter) Result {
+	rp := parseObjectPath(path, l)
+	d := &descender{json: json, lim: l, part: rp.part, wild: rp.wild,
+		fold: l.caseInsensitive() || rp.fold}
+	if d.fold && d.wild {
+		d.fpart = foldString(rp.part)
+	}
+	var rest, pipe string
+	var piped bool
+	if rp.more {
+		rest = rp.path
+		if left, right, ok := splitPossiblePipe(rest); ok {
+			rest, pipe, piped = left, right, true
+		}
+	} else if rp.piped {
+		pipe, piped = rp.pipe, true
+	}
+	found := d.walk()
+	if d.nodes == 0 || (l != nil && l.err != nil) {
+		return Result{}
+	}
+	each := isEachPath(rest)
+	b := []byte{'['}
+	indexes := make([]int, 0, len(found))
+	add := func(res Result) {
+		raw := res.Raw
+		if len(raw) == 0 {
+			raw = res.String()
+		}
+		if len(b) > 1 {
+			b = append(b, ',')
+		}
+		b = append(b, raw...)
+		indexes = append(indexes, rawIndex(json, res))
+		l.emit(len(raw) + 1)
+	}
+	for _, m := range found {
+		_, val, _ := parseAny(json, m.start, true)
+		val.Index = m.start
+		if rest == "" {
+			add(val)
+		} else {
+			var res Result
+			if l == nil {
+				res = val.child(getPath(val.Raw, rest, nil))
+			} else {
+				l.depth += m.depth
+				res = val.getLimit(rest, l)
+				l.depth -= m.depth
+			}
+			if each && res.IsArray() {
+				res.ForEach(func(_, elem Result) bool {
+					add(elem)
+					return true
+				})
+			} else if res.Exists() {
+				add(res)
+			}
+		}
+		if l != nil && l.err != nil {
+			return Result{}
+		}
+	}
+	b = append(b, ']')
+	res := Result{Type: JSON, Raw: string(b), Indexes: indexes}
+	if piped {
+		res = res.remap(getLimit(res.Raw, pipe, l))
+	}
+	return res
+}
+
+// isEachPath reports whether a path applies the components after a '#', a
+// query that returns all matches, or a recursive descent, to each of many
+// values, which makes an array of the results.
+func isEachPath(path string) bool {
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '.':
+			// the second '.' of a recursive descent
+			if i+1 < len(path) && isDescentChar(path[i+1]) {
+				return true
+			}
+		case '#':
+			if i+1 < len(path) && path[i+1] == '.' {
+				return true
+			}
+			if i+1 < len(path) && (path[i+1] == '(' || path[i+1] == '[') {
+				i, _ = parseSquash(path, i+1)
+				if i < len(path) && path[i] == '#' {
+					return true
+				}
+			}
+		case '[', '{':
+			i, _ = parseSquash(path, i)
+		case '@':
+			for i < len(path) && path[i] != '.' && path[i] != ':' {
+				i++
+			}
+			if i+1 < len(path) && path[i] == ':' {
+				switch path[i+1] {
+				case '{', '[':
+					i, _ = parseSquash(path, i+1)
+				case '"':
+					i, _, _, _ = parseString(path, i+2)
+				}
+			}
+		}
+		// skip to the next component
+		for ; i < len(path) && path[i] != '.'; i++ {
+			if path[i] == '\\' {
+				i++
+			}
+		}
+	}
+	return false
+}
+
+// descend applies the recursive descent at the start of path to the object
+// or array at c.json[i], and returns the position after it.
+func (c *parseContext) descend(i int, path string) int {
+	j, val := parseSquash(c.json, i)
+	c.value = c.get(Result{Type: JSON, Raw: val, Index: i}, path)
+	c.calcd = true
+	return j
+}
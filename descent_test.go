@@ -0,0 +1,159 @@
+package gjson
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const descentJSON = `{
+  "store": {
+    "items": [
+      {"name": "pen", "price": 5, "tags": [{"name": "blue"}]},
+      {"name": "book", "price": 20}
+    ],
+    "more": {"items": [{"name": "lamp", "price": 30}]}
+  },
+  "name": "shop",
+  "Name": "Shop"
+}`
+
+func TestDescent(t *testing.T) {
+	tests := []struct {
+		path  string
+		raw   string
+		paths string // the paths of the elements
+	}{
+		{"..name", `["shop","pen","blue","book","lamp"]`,
+			"name store.items.0.name store.items.0.tags.0.name " +
+				"store.items.1.name store.more.items.0.name"},
+		{"..items.#(price>10)#.name", `["book","lamp"]`,
+			"store.items.1.name store.more.items.0.name"},
+		{"..items.#.name", `["pen","book","lamp"]`,
+			"store.items.0.name store.items.1.name store.more.items.0.name"},
+		{"..items.0.price", `[5,30]`,
+			"store.items.0.price store.more.items.0.price"},
+		{"..items.#", `[2,1]`, " "},
+		{"..items.#.[name,price]", `[["pen",5],["book",20],["lamp",30]]`,
+			"  "},
+		{"store..name", `["pen","blue","book","lamp"]`,
+			"store.items.0.name store.items.0.tags.0.name " +
+				"store.items.1.name store.more.items.0.name"},
+		{"store.items.0..name", `["pen","blue"]`,
+			"store.items.0.name store.items.0.tags.0.name"},
+		{"..items..name", `["pen","blue","book","lamp"]`,
+			"store.items.0.name store.items.0.tags.0.name " +
+				"store.items.1.name store.more.items.0.name"},
+		{"..pri?e", `[5,20,30]`, "store.items.0.price store.items.1.price " +
+			"store.more.items.0.price"},
+		{"store|..price", `[5,20,30]`, "store.items.0.price " +
+			"store.items.1.price store.more.items.0.price"},
+		{"@this..price", `[5,20,30]`, "store.items.0.price " +
+			"store.items.1.price store.more.items.0.price"},
+		{"..missing", `[]`, ""},
+		{"store.items.0.name..x", ``, ""},
+	}
+	for _, tt := range tests {
+		res := Get(descentJSON, tt.path)
+		if res.Raw != tt.raw {
+			t.Fatalf("%q: expected %q, got %q", tt.path, tt.raw, res.Raw)
+		}
+		paths := strings.Join(res.Paths(descentJSON), " ")
+		if paths != tt.paths {
+			t.Fatalf("%q: expected paths %q, got %q", tt.path, tt.paths,
+				paths)
+		}
+	}
+
+	// the rest of the path after a pipe applies to the whole array
+	assert(t, Get(descentJSON, "..price|@reverse").Raw == `[30,20,5]`)
+	assert(t, Get(descentJSON, "..price|#").Int() == 3)
+	assert(t, Get(descentJSON, "..items|0.0.name").String() == "pen")
+	assert(t, Get(descentJSON, "..price.@reverse").Raw == `[30,20,5]`)
+	assert(t, Get(descentJSON, "[..price,name]").Raw == `[[5,20,30],"shop"]`)
+
+	// each element has its own span
+	start, _ := Get(descentJSON, "..price").Array()[2].Span(descentJSON)
+	assert(t, start.String() == "7:50")
+
+	// ".." followed by a digit or a '#' is still JSON Lines
+	lines := `{"name":"a"}` + "\n" + `{"x":{"name":"b"}}`
+	assert(t, Get(lines, "..#").Int() == 2)
+	assert(t, Get(lines, "..1.x.name").String() == "b")
+	assert(t, Get(lines, "..#..name").Raw == `[["a"],["b"]]`)
+	assert(t, Get(lines, `..\1`).Raw == `[]`)
+
+	// a recursive descent searches every line
+	assert(t, Get(lines, "..name").Raw == `["a","b"]`)
+	assert(t, Get(lines, "..x.name").Raw == `["b"]`)
+	assert(t, Get(lines, "..name|#").Int() == 2)
+	assert(t, Get(lines, "@dig:name").Raw == `["a","b"]`)
+	mixed := lines + "\n" + `"name"` + "\n" + `[{"name":"c"}]`
+	assert(t, Get(mixed, "..name").Raw == `["a","b","c"]`)
+	start, _ = Get(mixed, "..name").Array()[2].Span(mixed)
+	assert(t, mixed[start.Offset:start.Offset+3] == `"c"`)
+
+	// a scalar has no members
+	assert(t, !Get(`"name"`, "..name").Exists())
+}
+
+func TestDescentOptions(t *testing.T) {
+	res, err := GetWithOptions(descentJSON, "..NAME",
+		&Options{CaseInsensitive: true})
+	assert(t, err == nil && res.Raw ==
+		`["shop","Shop","pen","blue","book","lamp"]`)
+	res, err = GetWithOptions(descentJSON, "..~NAME",
+		&Options{CaseInsensitivePrefix: true})
+	assert(t, err == nil && res.Raw ==
+		`["shop","Shop","pen","blue","book","lamp"]`)
+	assert(t, Get(descentJSON, "..~NAME").Raw == `[]`)
+	_, err = GetWithOptions(descentJSON, "..name", &Options{MaxSteps: 10})
+	assert(t, errors.Is(err, ErrMaxSteps))
+	_, err = GetWithOptions(descentJSON, "..name", &Options{MaxDepth: 6})
+	assert(t, errors.Is(err, ErrMaxDepth))
+	_, err = GetWithOptions(descentJSON, "..name", &Options{MaxDepth: 7})
+	assert(t, err == nil)
+	_, err = GetWithOptions(descentJSON, "..items", &Options{MaxOutput: 50})
+	assert(t, errors.Is(err, ErrMaxOutput))
+}
+
+func TestDig(t *testing.T) {
+	// @dig with a key finds the same values as a recursive descent
+	assert(t, Get(descentJSON, "@dig:name").Raw ==
+		Get(descentJSON, "..name").Raw)
+	// the path is applied to each value, so each result is kept as it is
+	assert(t, Get(descentJSON, "@dig:items.#.name").Raw ==
+		`[["pen","book"],["lamp"]]`)
+	assert(t, Get(descentJSON, `@dig:items.#(name=="book")#.name`).Raw ==
+		`[["book"],[]]`)
+	assert(t, Get(`{"a":[1,"x"],"b":true}`, "@dig:@this").Raw ==
+		`[{"a":[1,"x"],"b":true},[1,"x"],1,"x",true]`)
+	assert(t, Get(`"x"`, "@dig:@this").Raw == `["x"]`)
+	assert(t, Get(descentJSON, "@dig:#(price>10).name").Raw ==
+		`["book","lamp"]`)
+	assert(t, Get(descentJSON, "@dig:0.name").Raw == `["pen","blue","lamp"]`)
+	assert(t, Get(`"x"`, "@dig:name").Raw == `[]`)
+	assert(t, Get(`"x"`, "@dig:0").Raw == `[]`)
+}
+
+func TestDigOptions(t *testing.T) {
+	// the path is applied to each value within the limits of the search
+	deep := strings.Repeat(`{"a":`, 20000) + "1" + strings.Repeat("}", 20000)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := GetWithOptions(deep, "@dig:zz",
+		&Options{MaxSteps: 100000, Context: ctx})
+	assert(t, errors.Is(err, ErrMaxSteps) && time.Since(start) < time.Second)
+	_, err = GetWithOptions(deep, "@dig:zz", &Options{MaxDepth: 100})
+	assert(t, errors.Is(err, ErrMaxDepth))
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = GetWithOptions(descentJSON, "@dig:name", &Options{Context: ctx})
+	assert(t, errors.Is(err, context.Canceled))
+	res, err := GetWithOptions(descentJSON, "@dig:name",
+		&Options{MaxSteps: 1000})
+	assert(t, err == nil && res.Raw == Get(descentJSON, "..name").Raw)
+}
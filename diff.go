@@ -0,0 +1,319 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gjson
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ChangeKind is the kind of a Change.
+type ChangeKind int
+
+const (
+	// Added is a value that only exists in the new document.
+	Added ChangeKind = iota + 1
+	// Removed is a value that only exists in the old document.
+	Removed
+	// Changed is a value that is different in the new document.
+	Changed
+)
+
+// String returns a string representation of the kind.
+func (k ChangeKind) String() string {
+	switch k {
+	default:
+		return ""
+	case Added:
+		return "Added"
+	case Removed:
+		return "Removed"
+	case Changed:
+		return "Changed"
+	}
+}
+
+// Change is a difference between two json documents, as returned by Diff.
+type Change struct {
+	// Kind is the kind of change.
+	Kind ChangeKind
+	// Path is the GJSON path of the value, with each key escaped by Escape.
+	// The path refers to the old document for removed and changed values,
+	// and to the new document for added values. The root is "@this". An
+	// empty key is written as nothing, and the key after it that isn't the
+	// first follows a '|', such as "a.|b" for {"a":{"":{"b":1}}}.
+	Path string
+	// Pointer is the RFC 6901 JSON Pointer of the value. It's the same
+	// location as Path, except for values added to an array that is compared
+	// without order, which are appended with the "-" index.
+	Pointer string
+	// Old is the value in the old document, for removed and changed values.
+	Old Result
+	// New is the value in the new document, for added and changed values.
+	New Result
+}
+
+// DiffOptions are the options for DiffWithOptions.
+type DiffOptions struct {
+	// IgnoreArrayOrder compares arrays as unordered collections, such that
+	// elements are only added or removed, and never moved.
+	IgnoreArrayOrder bool
+	// ArrayKey is a path, such as "id", that identifies the elements of
+	// arrays of objects. Elements with the same key are compared with each
+	// other regardless of their position. Elements without the key are
+	// compared as with IgnoreArrayOrder.
+	ArrayKey string
+}
+
+// DefaultDiffOptions is the default options for Diff.
+var DefaultDiffOptions = &DiffOptions{}
+
+// Diff returns the structural differences between two json documents.
+// Values are compared with Equal, so key order, whitespace, and number
+// spelling are ignored.
+//
+//	gjson.Diff(`{"a":1,"b":[1,2]}`, `{"a":2,"b":[1],"c":true}`)
+//	// Changed  a    1 -> 2
+//	// Removed  b.1  2
+//	// Added    c    true
+//
+// Changes are returned in an order that can be applied one at a time, such as
+// with the JSON Patch returned by Patch.
+func Diff(a, b string) []Change {
+	return DiffWithOptions(a, b, nil)
+}
+
+// DiffWithOptions is the same as Diff but with options.
+func DiffWithOptions(a, b string, opts *DiffOptions) []Change {
+	if opts == nil {
+		opts = DefaultDiffOptions
+	}
+	d := differ{opts: opts}
+	d.value("", "", Parse(a), Parse(b))
+	return d.changes
+}
+
+type differ struct {
+	opts    *DiffOptions
+	changes []Change
+}
+
+func (d *differ) add(kind ChangeKind, path, ptr string, a, b Result) {
+	if ptr == "" {
+		path = "@this"
+	}
+	d.changes = append(d.changes, Change{
+		Kind: kind, Path: path, Pointer: ptr, Old: a, New: b,
+	})
+}
+
+// childPath appends an escaped key to a path and a pointer. The key after
+// an empty key that isn't the first follows a '|' instead of a '.', since
+// ".." starts a recursive descent.
+func childPath(path, ptr, key string) (string, string) {
+	switch {
+	case ptr == "":
+	case endsWithSep(path):
+		path += "|"
+	default:
+		path += "."
+	}
+	return path + Escape(key), ptr + "/" + escapePointer(key)
+}
+
+// endsWithSep reports whether a path ends with a '.' or a '|' that isn't
+// escaped, which is after an empty key.
+func endsWithSep(path string) bool {
+	n := len(path)
+	if n == 0 || (path[n-1] != '.' && path[n-1] != '|') {
+		return false
+	}
+	var esc int
+	for i := n - 2; i >= 0 && path[i] == '\\'; i-- {
+		esc++
+	}
+	return esc%2 == 0
+}
+
+// escapePointer escapes a JSON Pointer reference token.
+func escapePointer(key string) string {
+	if strings.IndexByte(key, '~') == -1 && strings.IndexByte(key, '/') == -1 {
+		return key
+	}
+	key = strings.Replace(key, "~", "~0", -1)
+	return strings.Replace(key, "/", "~1", -1)
+}
+
+func (d *differ) value(path, ptr string, a, b Result) {
+	switch {
+	case a.IsObject() && b.IsObject():
+		d.object(path, ptr, a, b)
+	case a.IsArray() && b.IsArray():
+		if d.opts.IgnoreArrayOrder || d.opts.ArrayKey != "" {
+			d.unorderedArray(path, ptr, a, b)
+		} else {
+			d.array(path, ptr, a, b)
+		}
+	case !a.Equal(b):
+		d.add(Changed, path, ptr, a, b)
+	}
+}
+
+func (d *differ) object(path, ptr string, a, b Result) {
+	bkvs := make(map[string]Result)
+	var bkeys []string
+	b.ForEach(func(key, value Result) bool {
+		if _, ok := bkvs[key.Str]; !ok {
+			bkvs[key.Str] = value
+			bkeys = append(bkeys, key.Str)
+		}
+		return true
+	})
+	seen := make(map[string]bool)
+	a.ForEach(func(key, value Result) bool {
+		if seen[key.Str] {
+			return true
+		}
+		seen[key.Str] = true
+		cpath, cptr := childPath(path, ptr, key.Str)
+		if bvalue, ok := bkvs[key.Str]; ok {
+			d.value(cpath, cptr, value, bvalue)
+		} else {
+			d.add(Removed, cpath, cptr, value, Result{})
+		}
+		return true
+	})
+	for _, key := range bkeys {
+		if !seen[key] {
+			cpath, cptr := childPath(path, ptr, key)
+			d.add(Added, cpath, cptr, Result{}, bkvs[key])
+		}
+	}
+}
+
+func (d *differ) array(path, ptr string, a, b Result) {
+	avals, bvals := a.Array(), b.Array()
+	n := len(avals)
+	if len(bvals) < n {
+		n = len(bvals)
+	}
+	for i := 0; i < n; i++ {
+		cpath, cptr := childPath(path, ptr, strconv.Itoa(i))
+		d.value(cpath, cptr, avals[i], bvals[i])
+	}
+	for i := n; i < len(bvals); i++ {
+		cpath, cptr := childPath(path, ptr, strconv.Itoa(i))
+		d.add(Added, cpath, cptr, Result{}, bvals[i])
+	}
+	// remove from the end so that the indexes remain valid
+	for i := len(avals) - 1; i >= n; i-- {
+		cpath, cptr := childPath(path, ptr, strconv.Itoa(i))
+		d.add(Removed, cpath, cptr, avals[i], Result{})
+	}
+}
+
+func (d *differ) unorderedArray(path, ptr string, a, b Result) {
+	avals, bvals := a.Array(), b.Array()
+	amatch := make([]int, len(avals))
+	bmatched := make([]bool, len(bvals))
+	for i := range amatch {
+		amatch[i] = -1
+	}
+	if d.opts.ArrayKey != "" {
+		// match elements by key
+		bkeys := make(map[string]int)
+		for j, bval := range bvals {
+			if key := bval.Get(d.opts.ArrayKey); key.Exists() {
+				ckey := Canonical(key.rawJSON())
+				if _, ok := bkeys[ckey]; !ok {
+					bkeys[ckey] = j
+				}
+			}
+		}
+		for i, aval := range avals {
+			if key := aval.Get(d.opts.ArrayKey); key.Exists() {
+				j, ok := bkeys[Canonical(key.rawJSON())]
+				if ok && !bmatched[j] {
+					amatch[i] = j
+					bmatched[j] = true
+				}
+			}
+		}
+	}
+	// match the remaining elements by equality
+	buckets := make(map[uint64][]int)
+	for j, bval := range bvals {
+		if !bmatched[j] {
+			h := bval.Hash()
+			buckets[h] = append(buckets[h], j)
+		}
+	}
+	for i, aval := range avals {
+		if amatch[i] != -1 {
+			continue
+		}
+		h := aval.Hash()
+		for k, j := range buckets[h] {
+			if !bmatched[j] && aval.Equal(bvals[j]) {
+				amatch[i] = j
+				bmatched[j] = true
+				buckets[h] = append(buckets[h][:k], buckets[h][k+1:]...)
+				break
+			}
+		}
+	}
+	for i, j := range amatch {
+		if j != -1 {
+			cpath, cptr := childPath(path, ptr, strconv.Itoa(i))
+			d.value(cpath, cptr, avals[i], bvals[j])
+		}
+	}
+	// remove from the end so that the indexes remain valid
+	for i := len(avals) - 1; i >= 0; i-- {
+		if amatch[i] == -1 {
+			cpath, cptr := childPath(path, ptr, strconv.Itoa(i))
+			d.add(Removed, cpath, cptr, avals[i], Result{})
+		}
+	}
+	for j, bval := range bvals {
+		if !bmatched[j] {
+			cpath, _ := childPath(path, ptr, strconv.Itoa(j))
+			d.add(Added, cpath, ptr+"/-", Result{}, bval)
+		}
+	}
+}
+
+// Patch returns the changes as an RFC 6902 JSON Patch document, which is an
+// array of "add", "remove" and "replace" operations.
+//
+//	gjson.Patch(gjson.Diff(`{"a":1}`, `{"a":2}`))
+//	// [{"op":"replace","path":"/a","value":2}]
+func Patch(changes []Change) string {
+	var b []byte
+	b = append(b, '[')
+	for i, c := range changes {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, `{"op":`...)
+		switch c.Kind {
+		case Added:
+			b = append(b, `"add"`...)
+		case Removed:
+			b = append(b, `"remove"`...)
+		default:
+			b = append(b, `"replace"`...)
+		}
+		b = append(b, `,"path":`...)
+		b = AppendJSONString(b, c.Pointer)
+		if c.Kind != Removed {
+			b = append(b, `,"value":`...)
+			b = append(b, c.New.rawJSON()...)
+		}
+		b = append(b, '}')
+	}
+	b = append(b, ']')
+	return string(b)
+}
@@ -0,0 +1,87 @@
+package gjson
+
+import (
+	"strings"
+	"testing"
+)
+
+func formatChanges(changes []Change) string {
+	var lines []string
+	for _, c := range changes {
+		line := c.Kind.String() + " " + c.Path + " " + c.Pointer
+		if c.Kind != Added {
+			line += " " + c.Old.Raw
+		}
+		if c.Kind != Removed {
+			line += " " + c.New.Raw
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestDiff(t *testing.T) {
+	a := `{"name":"Tom","age":37,"tags":["a","b","c"],"a.b":{"x":1.0},
+		"nested":{"keep":true,"drop":null},"list":[1,2],"type":[1]}`
+	b := `{"name":"Tom","age":38,"tags":["a","c"],"a.b":{"x":1},
+		"nested":{"keep":true,"add/~":1},"list":[1,2,3],"type":{"0":1}}`
+	out := formatChanges(Diff(a, b))
+	assert(t, out == strings.Join([]string{
+		`Changed age /age 37 38`,
+		`Changed tags.1 /tags/1 "b" "c"`,
+		`Removed tags.2 /tags/2 "c"`,
+		`Removed nested.drop /nested/drop null`,
+		`Added nested.add\/\~ /nested/add~1~0 1`,
+		`Added list.2 /list/2 3`,
+		`Changed type /type [1] {"0":1}`,
+	}, "\n"))
+	assert(t, len(Diff(a, a)) == 0)
+	assert(t, len(Diff(`{"a":[1,{"b":2}]}`, `{ "a" : [ 1.0, { "b" : 2 } ] }`)) == 0)
+	out = formatChanges(Diff(`1`, `"1"`))
+	assert(t, out == `Changed @this  1 "1"`)
+	out = formatChanges(Diff(`{"a.b":{"c*":1}}`, `{"a.b":{"c*":2}}`))
+	assert(t, out == `Changed a\.b.c\* /a.b/c* 1 2`)
+	assert(t, Get(`{"a.b":{"c*":1}}`, `a\.b.c\*`).Int() == 1)
+
+	// an empty key isn't the root, and ".." isn't written after it
+	a = `{"":1,"a":{"":{"b":1,"":[1]}},"c\\.":{"":{"d":1}}}`
+	b = `{"":2,"a":{"":{"b":2,"":[2]}},"c\\.":{"":{"d":2}}}`
+	changes := Diff(a, b)
+	assert(t, formatChanges(changes) == strings.Join([]string{
+		`Changed  / 1 2`,
+		`Changed a.|b /a//b 1 2`,
+		`Changed a.||0 /a///0 1 2`,
+		`Changed c\\\..|d /c\.//d 1 2`,
+	}, "\n"))
+	for _, c := range changes {
+		assert(t, Get(a, c.Path).Raw == c.Old.Raw)
+	}
+}
+
+func TestDiffArrays(t *testing.T) {
+	a := `[1,2,3,{"x":1}]`
+	b := `[{"x":1},3,4,1]`
+	out := formatChanges(DiffWithOptions(a, b,
+		&DiffOptions{IgnoreArrayOrder: true}))
+	assert(t, out == "Removed 1 /1 2\nAdded 2 /- 4")
+
+	a = `{"users":[{"id":1,"name":"Tom"},{"id":2,"name":"Ann"},{"id":3}]}`
+	b = `{"users":[{"id":3},{"id":4},{"id":1,"name":"Tommy"}]}`
+	out = formatChanges(DiffWithOptions(a, b, &DiffOptions{ArrayKey: "id"}))
+	assert(t, out == strings.Join([]string{
+		`Changed users.0.name /users/0/name "Tom" "Tommy"`,
+		`Removed users.1 /users/1 {"id":2,"name":"Ann"}`,
+		`Added users.1 /users/- {"id":4}`,
+	}, "\n"))
+}
+
+func TestDiffPatch(t *testing.T) {
+	patch := Patch(Diff(`{"a":1,"b":[1,2],"c":{}}`,
+		`{"a":2,"b":[1],"d":"x"}`))
+	assert(t, patch == `[{"op":"replace","path":"/a","value":2},`+
+		`{"op":"remove","path":"/b/1"},{"op":"remove","path":"/c"},`+
+		`{"op":"add","path":"/d","value":"x"}]`)
+	assert(t, Patch(nil) == `[]`)
+	assert(t, Patch(Diff(`{"a":1}`, `[1]`)) ==
+		`[{"op":"replace","path":"","value":[1]}]`)
+}
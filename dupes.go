@@ -0,0 +1,143 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gjson
+
+import (
+	"errors"
+	"strconv"
+)
+
+// DuplicatePolicy is how a path key is resolved when an object has more than
+// one member with that key.
+type DuplicatePolicy int
+
+const (
+	// DuplicateFirst uses the first member with the key. This is the
+	// default.
+	DuplicateFirst DuplicatePolicy = iota
+	// DuplicateLast uses the last member with the key, which is what
+	// encoding/json does when unmarshalling.
+	DuplicateLast
+	// DuplicateError fails the search with ErrDuplicateKey.
+	DuplicateError
+)
+
+// ErrDuplicateKey is returned when a path key matches more than one member
+// of an object and the policy is DuplicateError.
+var ErrDuplicateKey = errors.New("gjson: duplicate key")
+
+// nextDuplicate returns the position after the next key that equals key in
+// the rest of an object, or -1 if there is none. The i is the position
+// after the key of the current member.
+func nextDuplicate(json string, i int, key string) int {
+	return nextKey(json, i, key, false)
+}
+
+// nextKey is like nextDuplicate, but when wild is true the key is a pattern
+// to match.
+func nextKey(json string, i int, key string, wild bool) int {
+	var ok bool
+	for ; i < len(json) && json[i] != ':'; i++ {
+	}
+	if i, _, ok = parseAny(json, i+1, true); !ok {
+		return -1
+	}
+	for ; i < len(json); i++ {
+		switch json[i] {
+		case '}':
+			return -1
+		case '/':
+			i = skipComment(json, i) - 1
+		case '"':
+			var k string
+			var esc bool
+			if i, k, esc, ok = parseString(json, i+1); !ok {
+				return -1
+			}
+			k = k[1 : len(k)-1]
+			if esc {
+				k = unescape(k)
+			}
+			if k == key || (wild && matchLimit(k, key)) {
+				return i
+			}
+			for ; i < len(json) && json[i] != ':'; i++ {
+			}
+			if i, _, ok = parseAny(json, i+1, true); !ok {
+				return -1
+			}
+			i--
+		}
+	}
+	return -1
+}
+
+// DuplicateKey is an object member with the same key as an earlier member
+// of the object, as returned by Duplicates.
+type DuplicateKey struct {
+	// Path is the GJSON path of the member, with each key escaped by Escape.
+	Path string
+	// Pointer is the RFC 6901 JSON Pointer of the member.
+	Pointer string
+	// Index is the position of the member's key in the json.
+	Index int
+}
+
+// Duplicates returns the object members in the json that have the same key
+// as an earlier member of the same object. Such json is valid, but RFC 8259
+// leaves its meaning undefined, and parsers disagree on which member wins.
+//
+//	gjson.Duplicates(`{"a":1,"b":{"c":1,"c":2},"a":3}`)
+//	// b.c  /b/c  18
+//	// a    /a    25
+func Duplicates(json string) []DuplicateKey {
+	var dupes []DuplicateKey
+	findDuplicates(Parse(json), "", "", &dupes)
+	return dupes
+}
+
+func findDuplicates(t Result, path, ptr string, dupes *[]DuplicateKey) {
+	if t.IsArray() {
+		var i int
+		t.ForEach(func(_, value Result) bool {
+			cpath, cptr := childPath(path, ptr, strconv.Itoa(i))
+			findDuplicates(value, cpath, cptr, dupes)
+			i++
+			return true
+		})
+	} else if t.IsObject() {
+		var seen map[string]bool
+		t.ForEach(func(key, value Result) bool {
+			cpath, cptr := childPath(path, ptr, key.Str)
+			if seen == nil {
+				seen = make(map[string]bool)
+			}
+			if seen[key.Str] {
+				*dupes = append(*dupes, DuplicateKey{
+					Path: cpath, Pointer: cptr, Index: key.Index,
+				})
+			}
+			seen[key.Str] = true
+			// the value of a duplicate may have duplicates of its own
+			findDuplicates(value, cpath, cptr, dupes)
+			return true
+		})
+	}
+}
+
+// @dupes returns the paths of the object members that have the same key as
+// an earlier member of the same object.
+//
+//	{"a":1,"b":{"c":1,"c":2},"a":3}  ->  ["b.c","a"]
+func modDupes(json, arg string) string {
+	out := []byte{'['}
+	for i, dupe := range Duplicates(json) {
+		if i > 0 {
+			out = append(out, ',')
+		}
+		out = AppendJSONString(out, dupe.Path)
+	}
+	return string(append(out, ']'))
+}
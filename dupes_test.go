@@ -0,0 +1,88 @@
+package gjson
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDuplicates(t *testing.T) {
+	json := `{"a":1,"b":{"c":1,"c":2,"c.":3,"c.":4},"a":3,"d":[{"x":0,"x":1}]}`
+	dupes := Duplicates(json)
+	assert(t, len(dupes) == 4)
+	assert(t, dupes[0].Path == "b.c" && dupes[0].Pointer == "/b/c")
+	assert(t, json[dupes[0].Index:dupes[0].Index+3] == `"c"`)
+	assert(t, dupes[1].Path == `b.c\.` && dupes[1].Pointer == "/b/c.")
+	assert(t, dupes[2].Path == "a" && dupes[2].Pointer == "/a")
+	assert(t, dupes[3].Path == "d.0.x" && dupes[3].Pointer == "/d/0/x")
+	assert(t, Duplicates(`{"a":{"b":1},"b":[1,{"a":2}]}`) == nil)
+	assert(t, Duplicates(`[1,`) == nil)
+	assert(t, Get(json, "@dupes").Raw == `["b.c","b.c\\.","a","d.0.x"]`)
+	assert(t, Get(`{"a":1}`, "@dupes").Raw == `[]`)
+
+	// the members of a duplicate are searched too
+	json = `{"a":{"x":1},"a":{"y":1,"y":2}}`
+	dupes = Duplicates(json)
+	assert(t, len(dupes) == 2 && dupes[0].Path == "a" && dupes[1].Path == "a.y")
+	assert(t, dupes[0].Index == 13 && dupes[1].Pointer == "/a/y")
+	dupes = Duplicates(`{"a":1,"b":{"c":1,"c":2},"a":3}`)
+	assert(t, len(dupes) == 2 && dupes[0].Index == 18 && dupes[1].Index == 25)
+}
+
+func TestDuplicatePolicy(t *testing.T) {
+	json := `{"a":1,"b":{"c":"x","d":true,"c":{"e":[1]}},"a":null,` +
+		`"f":[{"g":1,"g":2},{"g":3}],"a":"last","hi":1,"hi":2}`
+	tests := []struct {
+		path  string
+		first string
+		last  string
+		err   string // empty for ErrDuplicateKey
+	}{
+		{"a", `1`, `"last"`, ``},
+		{"b.c", `"x"`, `{"e":[1]}`, ``},
+		{"b.c.e.0", `1`, `1`, ``},
+		{"b.d", `true`, `true`, `true`},
+		{"f.#.g", `[1,3]`, `[2,3]`, ``},
+		{"f.1.g", `3`, `3`, `3`},
+		{"hi", `1`, `2`, ``},
+		{"h?", `1`, `1`, `1`},
+		{"@this|a", `1`, `"last"`, ``},
+	}
+	for _, tt := range tests {
+		for policy, expect := range []string{tt.first, tt.last, tt.err} {
+			e := Engine{DuplicateKeys: DuplicatePolicy(policy)}
+			res, err := e.GetE(json, tt.path)
+			if res.Raw != expect {
+				t.Fatalf("%s: policy %d: expected %s, got %s",
+					tt.path, policy, expect, res.Raw)
+			}
+			if (err != nil) != (expect == "") ||
+				(err != nil && !errors.Is(err, ErrDuplicateKey)) {
+				t.Fatalf("%s: policy %d: unexpected error %v",
+					tt.path, policy, err)
+			}
+			res = e.Get(json, tt.path)
+			assert(t, res.Raw == expect)
+			if res.Index > 0 {
+				assert(t, json[res.Index:res.Index+len(res.Raw)] == res.Raw)
+			}
+		}
+	}
+}
+
+func TestDuplicateKeyError(t *testing.T) {
+	json := `{"admin":false,"admin":true}`
+	e := Engine{DuplicateKeys: DuplicateError}
+	_, err := e.GetE(json, "admin")
+	assert(t, errors.Is(err, ErrDuplicateKey))
+	_, err = GetWithOptions(json, "admin",
+		&Options{DuplicateKeys: DuplicateError})
+	assert(t, errors.Is(err, ErrDuplicateKey))
+	res, err := GetWithOptions(json, "admin",
+		&Options{DuplicateKeys: DuplicateLast})
+	assert(t, err == nil && res.Bool())
+	// the options take precedence over the engine
+	e.Options = &Options{DuplicateKeys: DuplicateLast}
+	res, err = e.GetE(json, "admin")
+	assert(t, err == nil && res.Bool())
+	assert(t, !Get(json, "admin").Bool())
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gjson
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// rawJSON returns the json text of the result. Calculated results, which have
+// no Raw, are converted to their json form. Non-existent results are null.
+func (t Result) rawJSON() string {
+	if len(t.Raw) > 0 {
+		return t.Raw
+	}
+	switch t.Type {
+	case Number:
+		return t.String()
+	case String:
+		return string(AppendJSONString(nil, t.Str))
+	case True:
+		return "true"
+	case False:
+		return "false"
+	}
+	return "null"
+}
+
+// MarshalJSON implements json.Marshaler and returns the underlying json
+// value. A non-existent result is encoded as null.
+func (t Result) MarshalJSON() ([]byte, error) {
+	return []byte(t.rawJSON()), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler and parses the json value into
+// the result.
+func (t *Result) UnmarshalJSON(data []byte) error {
+	return t.parseValid("UnmarshalJSON", string(data))
+}
+
+// MarshalText implements encoding.TextMarshaler and returns the underlying
+// json value.
+func (t Result) MarshalText() ([]byte, error) {
+	return t.MarshalJSON()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler and parses the json value
+// into the result.
+func (t *Result) UnmarshalText(data []byte) error {
+	return t.parseValid("UnmarshalText", string(data))
+}
+
+// Scan implements sql.Scanner for reading json and jsonb columns. Text and
+// byte values are parsed as json, and other driver values are converted to
+// their json form. A NULL column becomes a non-existent result.
+func (t *Result) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*t = Result{}
+		return nil
+	case []byte:
+		return t.parseValid("Scan", string(v))
+	case string:
+		return t.parseValid("Scan", v)
+	case int64:
+		*t = Parse(strconv.FormatInt(v, 10))
+	case float64:
+		*t = Parse(strconv.FormatFloat(v, 'f', -1, 64))
+	case bool:
+		*t = Parse(strconv.FormatBool(v))
+	case time.Time:
+		*t = Parse(string(AppendJSONString(nil, v.Format(time.RFC3339Nano))))
+	default:
+		return errors.New("gjson: cannot scan unsupported type")
+	}
+	return nil
+}
+
+// parseValid parses json into the result, returning an error when the json
+// is not valid.
+func (t *Result) parseValid(fn, json string) error {
+	if !Valid(json) {
+		return &ConvError{Func: fn, Type: JSON, Raw: json, Err: ErrSyntax}
+	}
+	*t = Parse(json)
+	return nil
+}
+
+// Valuer is a Result that implements driver.Valuer, for writing a result to a
+// json or jsonb column.
+//
+//	db.Exec("INSERT INTO docs (body) VALUES ($1)", gjson.Valuer(res))
+//
+// This is a separate type because the Value method of Result already exists.
+type Valuer Result
+
+// Value implements driver.Valuer and returns the underlying json value as a
+// string. A non-existent result is written as NULL.
+func (v Valuer) Value() (driver.Value, error) {
+	t := Result(v)
+	if !t.Exists() {
+		return nil, nil
+	}
+	return t.rawJSON(), nil
+}
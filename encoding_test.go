@@ -0,0 +1,79 @@
+package gjson
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	_ json.Marshaler           = Result{}
+	_ json.Unmarshaler         = &Result{}
+	_ encoding.TextMarshaler   = Result{}
+	_ encoding.TextUnmarshaler = &Result{}
+	_ sql.Scanner              = &Result{}
+	_ driver.Valuer            = Valuer{}
+)
+
+func TestMarshalJSON(t *testing.T) {
+	json1 := `{"name":{"first":"Tom","last":"Anderson"},"children":["Sara"],
+		"age":37,"nick":null}`
+	type response struct {
+		Name     Result `json:"name"`
+		Children Result `json:"children"`
+		Age      Result `json:"age"`
+		Nick     Result `json:"nick"`
+		Count    Result `json:"count"`
+		Missing  Result `json:"missing"`
+	}
+	data, err := json.Marshal(response{
+		Name:     Get(json1, "name"),
+		Children: Get(json1, "children"),
+		Age:      Get(json1, "age"),
+		Nick:     Get(json1, "nick"),
+		Count:    Get(json1, "children.#"),
+		Missing:  Get(json1, "missing"),
+	})
+	assert(t, err == nil)
+	assert(t, string(data) == `{"name":{"first":"Tom","last":"Anderson"},`+
+		`"children":["Sara"],"age":37,"nick":null,"count":1,"missing":null}`)
+
+	var resp response
+	assert(t, json.Unmarshal(data, &resp) == nil)
+	assert(t, resp.Name.Get("last").String() == "Anderson")
+	assert(t, resp.Age.Int() == 37)
+	assert(t, resp.Children.IsArray())
+
+	var res Result
+	assert(t, res.UnmarshalJSON([]byte(`{"a":`)) != nil)
+	data, err = Get(`{"a":"x<y"}`, "a").MarshalText()
+	assert(t, err == nil && string(data) == `"x<y"`)
+	assert(t, res.UnmarshalText([]byte(` [1,2] `)) == nil &&
+		res.Get("1").Int() == 2)
+}
+
+func TestScanValue(t *testing.T) {
+	var res Result
+	assert(t, res.Scan([]byte(`{"id":1}`)) == nil && res.Get("id").Int() == 1)
+	assert(t, res.Scan(`"text"`) == nil && res.String() == "text")
+	assert(t, res.Scan(int64(-5)) == nil && res.Int() == -5)
+	assert(t, res.Scan(1.5) == nil && res.Float() == 1.5)
+	assert(t, res.Scan(true) == nil && res.Type == True)
+	tm := time.Date(2024, 3, 10, 22, 4, 5, 0, time.UTC)
+	assert(t, res.Scan(tm) == nil && res.Time().Equal(tm))
+	assert(t, res.Scan(nil) == nil && !res.Exists())
+	err := res.Scan([]byte(`{"id":`))
+	assert(t, errors.Is(err, ErrSyntax))
+	assert(t, res.Scan(struct{}{}) != nil)
+
+	v, err := Valuer(Get(`{"a":[1, 2]}`, "a")).Value()
+	assert(t, err == nil && v == `[1, 2]`)
+	v, err = Valuer(Get(`{"a":[1, 2]}`, "a.#")).Value()
+	assert(t, err == nil && v == `2`)
+	v, err = Valuer(Get(`{}`, "a")).Value()
+	assert(t, err == nil && v == nil)
+}
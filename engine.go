@@ -0,0 +1,160 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gjson
+
+import "strings"
+
+// Engine searches json with its own modifiers, syntax, and limits, so that
+// packages in the same program can use different policies.
+//
+// The zero value is ready to use and has the built-in modifiers. An Engine
+// must not be changed while it's in use.
+//
+// The package functions, such as Get, use a default engine that is
+// configured by the DisableModifiers and AddModifier globals.
+type Engine struct {
+	// DisableModifiers disables the modifier syntax, such as "@reverse".
+	DisableModifiers bool
+	// DisableLiterals disables the literal syntax, such as "!true".
+	DisableLiterals bool
+	// AllowedModifiers are the names of the only modifiers that may be used.
+	// A nil slice allows all modifiers.
+	AllowedModifiers []string
+	// CaseInsensitive matches the keys of a path to object keys without
+	// regard to case, using Unicode simple folding. An exact match wins over
+	// a folded match.
+	CaseInsensitive bool
+	// CaseInsensitivePrefix makes a path key with a '~' prefix, such as
+	// "~userid", match like CaseInsensitive. Otherwise the '~' is part of
+	// the key.
+	CaseInsensitivePrefix bool
+	// DuplicateKeys is the policy for path keys that match more than one
+	// member of an object. It does not apply to keys with wildcards.
+	DuplicateKeys DuplicatePolicy
+	// Options are the limits of each search. Get returns a non-existent
+	// result when a limit is exceeded or a modifier fails, while GetE
+	// returns the error.
+	Options *Options
+
+	modifiers map[string]ModifierFunc
+	// global uses the DisableModifiers global and the modifiers registered
+	// with AddModifier, as the default engine does.
+	global bool
+}
+
+// defaultEngine is the engine of the package functions, such as Get.
+var defaultEngine = &Engine{global: true}
+
+// AddModifier binds a custom modifier command to the engine. The built-in
+// modifiers may be replaced.
+func (e *Engine) AddModifier(name string, fn func(json, arg string) string) {
+	e.AddModifierFunc(name, StringModifier(fn))
+}
+
+// AddModifierFunc binds a custom ModifierFunc to the engine. The built-in
+// modifiers may be replaced.
+func (e *Engine) AddModifierFunc(name string, fn ModifierFunc) {
+	if e.modifiers == nil {
+		e.modifiers = make(map[string]ModifierFunc, len(builtinModifiers)+1)
+		for name, fn := range builtinModifiers {
+			e.modifiers[name] = StringModifier(fn)
+		}
+	}
+	e.modifiers[name] = fn
+}
+
+// ModifierExists returns true when the specified modifier exists and is
+// allowed by the engine. When fn is not nil, the modifier must also be that
+// function.
+func (e *Engine) ModifierExists(name string, fn func(json, arg string) string,
+) bool {
+	mfn, ok := e.modifier(name)
+	return ok && (fn == nil || sameModifier(fn, mfn))
+}
+
+func (e *Engine) modifier(name string) (ModifierFunc, bool) {
+	if e.global {
+		return lookupModifier(name)
+	}
+	if e.AllowedModifiers != nil {
+		var allowed bool
+		for _, allow := range e.AllowedModifiers {
+			if allow == name {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, false
+		}
+	}
+	if e.modifiers == nil {
+		fn, ok := builtinModifiers[name]
+		if !ok {
+			return nil, false
+		}
+		return StringModifier(fn), true
+	}
+	fn, ok := e.modifiers[name]
+	return fn, ok
+}
+
+// modifiersDisabled reports whether the modifier syntax is disabled.
+func (e *Engine) modifiersDisabled() bool {
+	return e.DisableModifiers || (e.global && DisableModifiers)
+}
+
+// plain reports whether a search for the path without limits is the same
+// with the engine as without it, so that it needs no limiter.
+func (e *Engine) plain(path string) bool {
+	return e.Options == nil && !e.DisableLiterals && !e.CaseInsensitive &&
+		!e.CaseInsensitivePrefix && e.DuplicateKeys == DuplicateFirst &&
+		// a modifier may need the root and may fail
+		strings.IndexByte(path, '@') < 0
+}
+
+// Get searches json for the specified path. See the Get function.
+func (e *Engine) Get(json, path string) Result {
+	if e.plain(path) {
+		return getPath(json, path, nil)
+	}
+	res, _ := e.GetE(json, path)
+	return res
+}
+
+// GetE searches json for the specified path, like Get, but returns an error
+// when a limit of the engine's Options is exceeded. See GetWithOptions.
+func (e *Engine) GetE(json, path string) (Result, error) {
+	return withLimits(e, e.Options, func(l *limiter) Result {
+		return getLimit(json, path, l)
+	})
+}
+
+// GetBytes searches json for the specified path. See the GetBytes function.
+func (e *Engine) GetBytes(json []byte, path string) Result {
+	if e.plain(path) {
+		return getBytes(json, path, nil)
+	}
+	res, _ := withLimits(e, e.Options, func(l *limiter) Result {
+		return getBytes(json, path, l)
+	})
+	return res
+}
+
+// GetMany searches json for the multiple paths. See the GetMany function.
+func (e *Engine) GetMany(json string, path ...string) []Result {
+	res := make([]Result, len(path))
+	for i, path := range path {
+		res[i] = e.Get(json, path)
+	}
+	return res
+}
+
+// Parse parses the json and returns a value. It's the same as the Parse
+// function, as parsing doesn't depend on the engine. Use the engine's Get
+// with the result's Raw to search the result with the engine.
+func (e *Engine) Parse(json string) Result {
+	return Parse(json)
+}
@@ -0,0 +1,109 @@
+package gjson
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestEngine(t *testing.T) {
+	json := `{"Name":{"First":"Tom"},"tags":["a","b"],"n":[1,2,3]}`
+
+	var e Engine
+	assert(t, e.Get(json, "tags.@reverse.0").String() == "b")
+	assert(t, e.Get(json, "!true").Bool())
+	assert(t, !e.Get(json, "name.first").Exists())
+	assert(t, e.ModifierExists("reverse", nil))
+	assert(t, !e.ModifierExists("upper", nil))
+
+	e.AddModifier("upper", func(json, arg string) string {
+		return strings.ToUpper(json)
+	})
+	assert(t, e.Get(json, "tags|@upper").Raw == `["A","B"]`)
+	assert(t, e.Get(json, "tags.@upper.1").String() == "B")
+	assert(t, e.Get(json, "tags|@reverse").Raw == `["b","a"]`)
+	assert(t, !Get(json, "tags|@upper").Exists())
+	assert(t, !ModifierExists("upper", nil))
+
+	e.AllowedModifiers = []string{"upper"}
+	assert(t, e.Get(json, "tags|@upper").Raw == `["A","B"]`)
+	assert(t, !e.Get(json, "tags|@reverse").Exists())
+	assert(t, !e.Get(json, "{a:tags|@reverse}").Get("a").Exists())
+	assert(t, !e.ModifierExists("reverse", nil))
+	assert(t, Get(json, "tags|@reverse").Raw == `["b","a"]`)
+
+	e = Engine{DisableModifiers: true, DisableLiterals: true}
+	assert(t, !e.Get(json, "tags|@reverse").Exists())
+	assert(t, !e.Get(json, "!true").Exists())
+	assert(t, e.Get(json, "[Name.First,!true]").Raw == `["Tom"]`)
+	assert(t, Get(json, "[Name.First,!true]").Raw == `["Tom",true]`)
+
+	e = Engine{CaseInsensitive: true}
+	assert(t, e.Get(json, "name.first").String() == "Tom")
+	assert(t, e.Get(json, "NAME.F*").String() == "Tom")
+	assert(t, e.Get(json, "TAGS.#").Int() == 2)
+	assert(t, e.Get(`[{"A":1},{"a":2}]`, "#(a==2).A").Int() == 2)
+	assert(t, e.GetBytes([]byte(json), "name.first").String() == "Tom")
+	res := e.GetMany(json, "name.first", "TAGS.1", "missing")
+	assert(t, len(res) == 3 && res[0].String() == "Tom" &&
+		res[1].String() == "b" && !res[2].Exists())
+	assert(t, e.Parse(json).Get("Name.First").String() == "Tom")
+
+	e = Engine{DuplicateKeys: DuplicateLast}
+	assert(t, e.Get(`{"a":1,"a":2}`, "a").Int() == 2)
+	assert(t, Get(`{"a":1,"a":2}`, "a").Int() == 1)
+
+	e = Engine{Options: &Options{MaxSteps: 2}}
+	assert(t, e.Get(json, "Name.First").String() == "Tom")
+	assert(t, !e.Get(json, "n.2").Exists())
+	_, err := e.GetE(json, "n.2")
+	assert(t, errors.Is(err, ErrMaxSteps))
+	res2, err := e.GetE(json, "Name")
+	assert(t, err == nil && res2.IsObject())
+}
+
+func TestDefaultEngine(t *testing.T) {
+	json := `{"a":{"b":1},"c":[1,2]}`
+	AddModifier("engine_test", func(json, arg string) string {
+		return `"x"`
+	})
+	defer UnregisterModifier("engine_test")
+	assert(t, defaultEngine.ModifierExists("engine_test", nil))
+	assert(t, Get(json, "@engine_test").String() == "x")
+	assert(t, GetBytes([]byte(json), "@engine_test").String() == "x")
+	res := GetMany(json, "a.b", "@engine_test")
+	assert(t, res[0].Int() == 1 && res[1].String() == "x")
+	DisableModifiers = true
+	assert(t, !Get(json, "c|@reverse").Exists())
+	DisableModifiers = false
+	assert(t, Get(json, "c|@reverse").Raw == `[2,1]`)
+
+	// a search without options or modifiers needs no limiter
+	var e Engine
+	data := []byte(json)
+	allocs := testing.AllocsPerRun(100, func() {
+		Get(json, "a.b")
+		e.Get(json, "c.1")
+		e.GetBytes(data, "c.#")
+	})
+	assert(t, allocs == 0)
+}
+
+func TestEngineConcurrent(t *testing.T) {
+	a := &Engine{}
+	a.AddModifier("x", func(json, arg string) string { return `"a"` })
+	b := &Engine{DisableModifiers: true}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				assert(t, a.Get(`{}`, "@x").String() == "a")
+				assert(t, !b.Get(`{}`, "@x").Exists())
+			}
+		}()
+	}
+	wg.Wait()
+}
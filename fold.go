@@ -0,0 +1,58 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gjson
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// foldRune returns the smallest rune that's equal to r under Unicode simple
+// folding.
+func foldRune(r rune) rune {
+	if r < utf8.RuneSelf {
+		if r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		return r
+	}
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return min
+}
+
+// foldString returns s with each rune folded by foldRune, so that two
+// strings are the same after folding when strings.EqualFold reports true.
+// Invalid UTF-8 is kept as is.
+func foldString(s string) string {
+	var b []byte
+	for i := 0; i < len(s); {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		f := r
+		if r != utf8.RuneError || n != 1 {
+			f = foldRune(r)
+		}
+		if f != r && b == nil {
+			b = make([]byte, i, len(s)+utf8.UTFMax)
+			copy(b, s[:i])
+		}
+		if b != nil {
+			if f == r {
+				b = append(b, s[i:i+n]...)
+			} else {
+				b = utf8.AppendRune(b, f)
+			}
+		}
+		i += n
+	}
+	if b == nil {
+		return s
+	}
+	return string(b)
+}
@@ -0,0 +1,69 @@
+package gjson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFoldString(t *testing.T) {
+	for _, s := range []string{"", "userid", "UserID", "straße", "ǅ",
+		"KK", "Σσς"} {
+		for _, s2 := range []string{strings.ToUpper(s), strings.ToLower(s),
+			strings.ToTitle(s)} {
+			assert(t, strings.EqualFold(s, s2) ==
+				(foldString(s) == foldString(s2)))
+		}
+	}
+	assert(t, foldString("k") == foldString("K"))
+	assert(t, foldString("ſ") == foldString("S"))
+	assert(t, foldString("a\xffb") == "A\xffB")
+	assert(t, foldString("123") == "123")
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	json := `{"UserID":1,"user":{"Name":"Tom"},"userid":2,"ΣΑΣ":3,` +
+		`"Kelvin":4}`
+	e := Engine{CaseInsensitive: true}
+	assert(t, e.Get(json, "USERID").Int() == 1)
+	assert(t, e.Get(json, "UserID").Int() == 1)
+	// an exact match wins, even when it comes later
+	assert(t, e.Get(json, "userid").Int() == 2)
+	assert(t, e.Get(json, "USER.name").String() == "Tom")
+	assert(t, e.Get(json, "σας").Int() == 3)
+	assert(t, e.Get(json, "Kelvin").Int() == 4)
+	assert(t, e.Get(json, "USER?D").Int() == 1)
+	assert(t, e.Get(json, "user?d").Int() == 2)
+	assert(t, e.Get(json, "σ*").Int() == 3)
+	assert(t, !e.Get(json, "users").Exists())
+
+	// per call
+	res, err := GetWithOptions(json, "USER.NAME",
+		&Options{CaseInsensitive: true})
+	assert(t, err == nil && res.String() == "Tom")
+	assert(t, !Get(json, "USER.NAME").Exists())
+
+	// path component prefix
+	p := &Engine{CaseInsensitivePrefix: true}
+	assert(t, p.Get(json, "~USER.Name").String() == "Tom")
+	assert(t, !p.Get(json, "~USER.NAME").Exists())
+	assert(t, p.Get(json, "~USER.~NAME").String() == "Tom")
+	assert(t, p.Get(json, "~USERID").Int() == 1)
+	assert(t, p.Get(json, "~userid").Int() == 2)
+	assert(t, p.Get(json, "~US*D").Int() == 1)
+	assert(t, p.Get(`{"~a":1,"a":2}`, `\~a`).Int() == 1)
+	assert(t, p.Get(`{"~a":1,"A":2}`, `~a`).Int() == 2)
+	assert(t, p.Get(`{"~":1}`, `~`).Int() == 1)
+	assert(t, p.Get(`[{"A":1},{"a":2}]`, `#.~a`).Raw == `[1,2]`)
+	assert(t, p.Get(`[{"A":1},{"a":2}]`, `#(~a==1).A`).Int() == 1)
+	res, err = GetWithOptions(json, "~USER.Name",
+		&Options{CaseInsensitivePrefix: true})
+	assert(t, err == nil && res.String() == "Tom")
+
+	// without the flag, the '~' is part of the key
+	assert(t, !Get(json, "~USER.Name").Exists())
+	assert(t, Get(`{"~home":1}`, "~home").Int() == 1)
+	assert(t, Get(`{"~home":1,"home":2}`, "~home").Int() == 1)
+	assert(t, Get(`{"~home":1,"HOME":2}`, "~home").Int() == 1)
+	assert(t, Get(`[{"~a":1},{"a":2}]`, `#.~a`).Raw == `[1]`)
+	assert(t, Escape("~a") == `\~a`)
+}
@@ -0,0 +1,243 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	resultType = reflect.TypeOf(Result{})
+	numberType = reflect.TypeOf(json.Number(""))
+)
+
+// GetAs searches json for the specified path and converts the result to T.
+//
+//	name, err := gjson.GetAs[string](json, "name.last")
+//	age, err := gjson.GetAs[int](json, "age")
+//	friends, err := gjson.GetAs[[]Friend](json, "friends")
+//
+// See As for the conversion rules.
+func GetAs[T any](json, path string) (T, error) {
+	var v T
+	err := convertAs("GetAs", Get(json, path), &v)
+	return v, err
+}
+
+// GetBytesAs searches json for the specified path and converts the result
+// to T. If working with bytes, this method preferred over
+// GetAs(string(data), path)
+func GetBytesAs[T any](json []byte, path string) (T, error) {
+	var v T
+	err := convertAs("GetBytesAs", GetBytes(json, path), &v)
+	return v, err
+}
+
+// As converts a result to T using the same rules as the Result accessors,
+// such as String and Int.
+//
+//	string kinds          String
+//	bool kinds            Bool
+//	int and uint kinds    Int and Uint, checked against the size of T
+//	float kinds           Float, checked against the size of T
+//	time.Time             TimeE
+//	json.Number           Number
+//	gjson.Result          the result itself
+//	interface{}           Value
+//
+// Other types, such as structs, maps, slices and pointers, are decoded from
+// the raw json with encoding/json.
+//
+// A ConvError is returned when the value does not exist, does not fit in T,
+// is not a time or a number for time.Time and json.Number, or cannot be
+// decoded. Use the strict accessors, such as IntE, to reject values that
+// would otherwise be converted with loss.
+func As[T any](res Result) (T, error) {
+	var v T
+	err := convertAs("As", res, &v)
+	return v, err
+}
+
+// ArrayOf converts each element of an array to T. The elements are the same
+// as those returned by Result.Array, and each one is converted as in As.
+func ArrayOf[T any](res Result) ([]T, error) {
+	if res.Type == Null {
+		return []T{}, nil
+	}
+	if !res.IsArray() {
+		var v T
+		if err := convertAs("ArrayOf", res, &v); err != nil {
+			return nil, &ElemError{Key: "0", Err: err}
+		}
+		return []T{v}, nil
+	}
+	vals := make([]T, 0, 8)
+	var err error
+	res.ForEach(func(_, value Result) bool {
+		var v T
+		if err = convertAs("ArrayOf", value, &v); err != nil {
+			err = &ElemError{Key: strconv.Itoa(len(vals)), Err: err}
+			return false
+		}
+		vals = append(vals, v)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vals, nil
+}
+
+// MapOf converts each member of an object to T. The members are the same as
+// those returned by Result.Map, and each one is converted as in As.
+func MapOf[T any](res Result) (map[string]T, error) {
+	vals := make(map[string]T)
+	if !res.IsObject() {
+		return vals, nil
+	}
+	var err error
+	res.ForEach(func(key, value Result) bool {
+		if _, ok := vals[key.Str]; ok {
+			// the first member wins, as with Result.Map
+			return true
+		}
+		var v T
+		if err = convertAs("MapOf", value, &v); err != nil {
+			err = &ElemError{Key: key.Str, Err: err}
+			return false
+		}
+		vals[key.Str] = v
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vals, nil
+}
+
+// ElemError records a failed conversion of an array element or object member
+// by ArrayOf or MapOf.
+type ElemError struct {
+	Key string // the array index or object key
+	Err error  // the conversion error
+}
+
+func (e *ElemError) Error() string {
+	return "gjson: element " + strconv.Quote(e.Key) + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ElemError) Unwrap() error {
+	return e.Err
+}
+
+// convertAs converts res into the value pointed to by ptr.
+func convertAs(fn string, res Result, ptr interface{}) (err error) {
+	if p, ok := ptr.(*Result); ok {
+		*p = res
+		return nil
+	}
+	if !res.Exists() {
+		return res.convError(fn, ErrNotExist)
+	}
+	// fast paths for the most common types
+	switch p := ptr.(type) {
+	case *string:
+		*p = res.String()
+	case *bool:
+		*p = res.Bool()
+	case *int64:
+		*p = res.Int()
+	case *uint64:
+		*p = res.Uint()
+	case *float64:
+		*p = res.Float()
+	case *time.Time:
+		*p, err = res.TimeE()
+		return renameConvError(fn, err)
+	default:
+		return convertValue(fn, res, reflect.ValueOf(ptr).Elem())
+	}
+	return nil
+}
+
+func convertValue(fn string, res Result, v reflect.Value) (err error) {
+	switch v.Type() {
+	case resultType:
+		v.Set(reflect.ValueOf(res))
+		return nil
+	case timeType:
+		var tm time.Time
+		tm, err = res.TimeE()
+		if err == nil {
+			v.Set(reflect.ValueOf(tm))
+		}
+		return renameConvError(fn, err)
+	case numberType:
+		n := res.Number()
+		if n == "" {
+			return res.convError(fn, ErrType)
+		}
+		v.SetString(string(n))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(res.String())
+	case reflect.Bool:
+		v.SetBool(res.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64:
+		n := res.Int()
+		if v.OverflowInt(n) {
+			return res.convError(fn, ErrRange)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		n := res.Uint()
+		if v.OverflowUint(n) {
+			return res.convError(fn, ErrRange)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f := res.Float()
+		if v.OverflowFloat(f) {
+			return res.convError(fn, ErrRange)
+		}
+		v.SetFloat(f)
+	case reflect.Interface:
+		if val := res.Value(); val != nil {
+			rv := reflect.ValueOf(val)
+			if !rv.Type().AssignableTo(v.Type()) {
+				return res.convError(fn, ErrType)
+			}
+			v.Set(rv)
+		}
+	default:
+		raw := res.Raw
+		if len(raw) == 0 {
+			raw = res.String()
+		}
+		err := json.Unmarshal(stringBytes(raw), v.Addr().Interface())
+		if err != nil {
+			return res.convError(fn, err)
+		}
+	}
+	return nil
+}
+
+// renameConvError sets the Func of a ConvError returned by TimeE to the name
+// of the generic function.
+func renameConvError(fn string, err error) error {
+	if cerr, ok := err.(*ConvError); ok {
+		cerr.Func = fn
+	}
+	return err
+}
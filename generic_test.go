@@ -0,0 +1,128 @@
+package gjson
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+const genericJSON = `{
+	"name": {"first": "Tom", "last": "Anderson"},
+	"age": 37,
+	"big": 300,
+	"height": 1.85,
+	"admin": true,
+	"created": "2024-03-10T22:04:05Z",
+	"children": ["Sara", "Alex", "Jack"],
+	"scores": [90, 85.5, "77"],
+	"friends": [
+		{"first": "Dale", "last": "Murphy", "age": 44},
+		{"first": "Roger", "last": "Craig", "age": 68}
+	],
+	"limits": {"cpu": 2, "memory": 512, "cpu": 4}
+}`
+
+type genericFriend struct {
+	First string `json:"first"`
+	Last  string `json:"last"`
+	Age   int    `json:"age"`
+}
+
+type genericLevel uint8
+
+func TestGetAs(t *testing.T) {
+	s, err := GetAs[string](genericJSON, "name.last")
+	assert(t, err == nil && s == "Anderson")
+	n, err := GetAs[int](genericJSON, "age")
+	assert(t, err == nil && n == 37)
+	n8, err := GetAs[int8](genericJSON, "age")
+	assert(t, err == nil && n8 == 37)
+	_, err = GetAs[int8](genericJSON, "big")
+	assert(t, errors.Is(err, ErrRange))
+	_, err = GetAs[genericLevel](genericJSON, "big")
+	assert(t, errors.Is(err, ErrRange))
+	f, err := GetAs[float32](genericJSON, "height")
+	assert(t, err == nil && f == 1.85)
+	// the same coercion as Result.Int and Result.String
+	n, err = GetAs[int](genericJSON, "height")
+	assert(t, err == nil && n == 1)
+	s, err = GetAs[string](genericJSON, "age")
+	assert(t, err == nil && s == "37")
+	b, err := GetAs[bool](genericJSON, "admin")
+	assert(t, err == nil && b)
+	tm, err := GetAs[time.Time](genericJSON, "created")
+	assert(t, err == nil && tm.Equal(time.Unix(1710108245, 0)))
+	friend, err := GetAs[genericFriend](genericJSON, "friends.1")
+	assert(t, err == nil && friend == genericFriend{"Roger", "Craig", 68})
+	friends, err := GetAs[[]genericFriend](genericJSON, "friends")
+	assert(t, err == nil && len(friends) == 2 && friends[0].Age == 44)
+	ptr, err := GetAs[*genericFriend](genericJSON, "friends.0")
+	assert(t, err == nil && ptr.First == "Dale")
+	res, err := GetAs[Result](genericJSON, "name")
+	assert(t, err == nil && res.IsObject())
+	v, err := GetAs[interface{}](genericJSON, "children.0")
+	assert(t, err == nil && v == "Sara")
+	count, err := GetAs[int](genericJSON, "children.#")
+	assert(t, err == nil && count == 3)
+
+	_, err = GetAs[string](genericJSON, "missing")
+	var cerr *ConvError
+	assert(t, errors.As(err, &cerr) && cerr.Func == "GetAs" &&
+		errors.Is(err, ErrNotExist))
+	_, err = GetAs[time.Time](genericJSON, "name.first")
+	assert(t, errors.As(err, &cerr) && cerr.Func == "GetAs")
+	_, err = GetAs[genericFriend](genericJSON, "missing")
+	assert(t, errors.Is(err, ErrNotExist))
+	_, err = GetAs[genericFriend](genericJSON, "children")
+	assert(t, err != nil)
+
+	s, err = GetBytesAs[string]([]byte(genericJSON), "name.first")
+	assert(t, err == nil && s == "Tom")
+	_, err = GetBytesAs[string]([]byte(genericJSON), "missing")
+	assert(t, errors.As(err, &cerr) && cerr.Func == "GetBytesAs")
+	n, err = As[int](Parse(`"42"`))
+	assert(t, err == nil && n == 42)
+}
+
+func TestArrayOf(t *testing.T) {
+	names, err := ArrayOf[string](Get(genericJSON, "children"))
+	assert(t, err == nil && len(names) == 3 && names[2] == "Jack")
+	scores, err := ArrayOf[float64](Get(genericJSON, "scores"))
+	assert(t, err == nil && len(scores) == 3 && scores[1] == 85.5 &&
+		scores[2] == 77)
+	friends, err := ArrayOf[genericFriend](Get(genericJSON, "friends"))
+	assert(t, err == nil && len(friends) == 2 && friends[1].Last == "Craig")
+	ages, err := ArrayOf[int](Get(genericJSON, "friends.#.age"))
+	assert(t, err == nil && len(ages) == 2 && ages[1] == 68)
+	single, err := ArrayOf[int](Get(genericJSON, "age"))
+	assert(t, err == nil && len(single) == 1 && single[0] == 37)
+	empty, err := ArrayOf[int](Get(genericJSON, "missing"))
+	assert(t, err == nil && len(empty) == 0)
+
+	ints, err := ArrayOf[int](Get(genericJSON, "scores"))
+	assert(t, err == nil && len(ints) == 3 && ints[1] == 85 && ints[2] == 77)
+	strs, err := ArrayOf[string](Parse(`[1,"a",true]`))
+	assert(t, err == nil && len(strs) == 3 && strs[0] == "1" &&
+		strs[2] == "true")
+
+	_, err = ArrayOf[uint8](Parse(`[1,300]`))
+	var eerr *ElemError
+	assert(t, errors.As(err, &eerr) && eerr.Key == "1" &&
+		errors.Is(err, ErrRange))
+}
+
+func TestMapOf(t *testing.T) {
+	limits, err := MapOf[int](Get(genericJSON, "limits"))
+	assert(t, err == nil && len(limits) == 2 && limits["cpu"] == 2 &&
+		limits["memory"] == 512)
+	names, err := MapOf[string](Get(genericJSON, "name"))
+	assert(t, err == nil && names["first"] == "Tom")
+	empty, err := MapOf[string](Get(genericJSON, "children"))
+	assert(t, err == nil && len(empty) == 0)
+	zeros, err := MapOf[int](Get(genericJSON, "name"))
+	assert(t, err == nil && len(zeros) == 2 && zeros["first"] == 0)
+	_, err = MapOf[int8](Get(genericJSON, "limits"))
+	var eerr *ElemError
+	assert(t, errors.As(err, &eerr) && eerr.Key == "memory" &&
+		errors.Is(err, ErrRange))
+}
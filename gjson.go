@@ -8,6 +8,11 @@
 package gjson
 
 import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -70,7 +75,8 @@ type Result struct {
 	// Index of raw value in original json, zero means index unknown
 	Index int
 	// Indexes of all the elements that match on a path containing the '#'
-	// query character.
+	// query character, where zero means the index of an element is unknown,
+	// such as when it's computed by a modifier.
 	Indexes []int
 }
 
@@ -197,12 +203,568 @@ func (t Result) Float() float64 {
 	}
 }
 
+// Errors returned by the strict accessors, such as IntE and BoolE, wrapped in
+// a ConvError.
+var (
+	// ErrNotExist is returned when the value does not exist.
+	ErrNotExist = errors.New("value does not exist")
+	// ErrType is returned when the json type cannot be converted.
+	ErrType = errors.New("wrong json type")
+	// ErrRange is returned when the value does not fit in the target type.
+	ErrRange = errors.New("value out of range")
+	// ErrFraction is returned when converting a number with a fractional
+	// part to an integer.
+	ErrFraction = errors.New("value has a fractional part")
+	// ErrSyntax is returned when a string does not hold the expected value.
+	ErrSyntax = errors.New("invalid syntax")
+)
+
+// ConvError records a failed conversion of a Result.
+type ConvError struct {
+	Func string // the failing method, such as "IntE"
+	Type Type   // the json type of the value
+	Raw  string // the raw json of the value
+	Err  error  // the reason the conversion failed, such as ErrRange
+}
+
+func (e *ConvError) Error() string {
+	if e.Err == ErrNotExist {
+		return "gjson." + e.Func + ": " + e.Err.Error()
+	}
+	return "gjson." + e.Func + ": converting " + e.Type.String() + " " +
+		strconv.Quote(e.Raw) + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ConvError) Unwrap() error {
+	return e.Err
+}
+
+func (t Result) convError(fn string, err error) *ConvError {
+	if !t.Exists() {
+		err = ErrNotExist
+	}
+	return &ConvError{Func: fn, Type: t.Type, Raw: t.Raw, Err: err}
+}
+
+// strictNumber returns the text of a Number, or of a String holding a
+// number, for the strict numeric accessors.
+func (t Result) strictNumber(fn string) (string, error) {
+	switch t.Type {
+	case Number, String:
+		raw, _ := t.numberText()
+		return raw, nil
+	}
+	return "", t.convError(fn, ErrType)
+}
+
+// integerError returns the reason that raw could not be converted to an
+// integer, after the fast integer parsing has failed.
+func (t Result) integerError(fn, raw string) *ConvError {
+	_, _, exp, ok := decimalParts(raw)
+	if !ok {
+		if !isNumber(raw) {
+			return t.convError(fn, ErrSyntax)
+		}
+		// the exponent has too many digits, such as 1e9999999999
+		if strings.Contains(raw, "e-") || strings.Contains(raw, "E-") {
+			return t.convError(fn, ErrFraction)
+		}
+		return t.convError(fn, ErrRange)
+	}
+	if exp > 0 {
+		// a whole number that's too large to expand, such as 1e999999
+		return t.convError(fn, ErrRange)
+	}
+	return t.convError(fn, ErrFraction)
+}
+
+// IntE returns an integer representation, or an error when the value cannot
+// be converted without loss.
+//
+// Numbers, and strings that contain a number, are converted. A ConvError
+// wrapping ErrRange is returned when the value does not fit in an int64,
+// ErrFraction when the value is not a whole number, ErrType for other json
+// types, and ErrNotExist when the value does not exist.
+func (t Result) IntE() (int64, error) {
+	raw, err := t.strictNumber("IntE")
+	if err != nil {
+		return 0, err
+	}
+	n, ok, overflow := parseIntRange(raw)
+	if ok {
+		if overflow {
+			return 0, t.convError("IntE", ErrRange)
+		}
+		return n, nil
+	}
+	// not a plain integer, such as 1.0 or 1e3
+	b, ok := t.BigInt()
+	if !ok {
+		return 0, t.integerError("IntE", raw)
+	}
+	if !b.IsInt64() {
+		return 0, t.convError("IntE", ErrRange)
+	}
+	return b.Int64(), nil
+}
+
+// UintE returns an unsigned integer representation, or an error when the
+// value cannot be converted without loss. Negative numbers are out of range.
+// See IntE for the possible errors.
+func (t Result) UintE() (uint64, error) {
+	raw, err := t.strictNumber("UintE")
+	if err != nil {
+		return 0, err
+	}
+	n, ok, overflow := parseUintRange(raw)
+	if ok {
+		if overflow {
+			return 0, t.convError("UintE", ErrRange)
+		}
+		return n, nil
+	}
+	b, ok := t.BigInt()
+	if !ok {
+		return 0, t.integerError("UintE", raw)
+	}
+	if !b.IsUint64() {
+		return 0, t.convError("UintE", ErrRange)
+	}
+	return b.Uint64(), nil
+}
+
+// FloatE returns a float64 representation, or an error when the value cannot
+// be converted. A ConvError wrapping ErrRange is returned when the magnitude
+// is too large for a float64, ErrSyntax when a string does not hold a number,
+// ErrType for other json types, and ErrNotExist when the value does not exist.
+func (t Result) FloatE() (float64, error) {
+	if t.Type == Number && len(t.Raw) == 0 {
+		// calculated result
+		return t.Num, nil
+	}
+	raw, err := t.strictNumber("FloatE")
+	if err != nil {
+		return 0, err
+	}
+	if strings.Trim(raw, "0123456789+-.eE") != "" {
+		// words such as NaN and Infinity, and hex floats, are accepted by
+		// ParseFloat but are not numbers
+		return 0, t.convError("FloatE", ErrSyntax)
+	}
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		if err.(*strconv.NumError).Err == strconv.ErrRange {
+			return 0, t.convError("FloatE", ErrRange)
+		}
+		return 0, t.convError("FloatE", ErrSyntax)
+	}
+	return f, nil
+}
+
+// BoolE returns a boolean representation, or an error when the value cannot
+// be converted. Booleans are returned as is, and strings are parsed using the
+// same rules as Bool. A ConvError wrapping ErrSyntax is returned when a string
+// does not hold a boolean, ErrType for other json types, and ErrNotExist when
+// the value does not exist.
+func (t Result) BoolE() (bool, error) {
+	switch t.Type {
+	case True:
+		return true, nil
+	case False:
+		return false, nil
+	case String:
+		b, err := strconv.ParseBool(strings.ToLower(t.Str))
+		if err != nil {
+			return false, t.convError("BoolE", ErrSyntax)
+		}
+		return b, nil
+	}
+	return false, t.convError("BoolE", ErrType)
+}
+
+// StringE returns the value of a json string, or an error when the value is
+// not a string. A ConvError wrapping ErrType is returned for other json types,
+// and ErrNotExist when the value does not exist.
+func (t Result) StringE() (string, error) {
+	if t.Type != String {
+		return "", t.convError("StringE", ErrType)
+	}
+	return t.Str, nil
+}
+
+// maxDecimalExp limits the size of the exponent that BigInt and Decimal will
+// expand into digits.
+const maxDecimalExp = 1 << 14
+
+// decimalParts splits a number into its sign, significant digits and base 10
+// exponent, such that the value equals digits*10^exp. Leading zeros are
+// removed from digits, and zero is "0" with the exponent of its fraction,
+// such as -2 for 0.00. The ok return is false when raw isn't in the JSON
+// number grammar, like for Number, or its exponent is out of range.
+func decimalParts(raw string) (neg bool, digits string, exp int, ok bool) {
+	if !isNumber(raw) {
+		return false, "", 0, false
+	}
+	var i int
+	if raw[i] == '-' {
+		neg = true
+		i++
+	}
+	s := i
+	for i < len(raw) && raw[i] >= '0' && raw[i] <= '9' {
+		i++
+	}
+	ipart := raw[s:i]
+	var fpart string
+	if i < len(raw) && raw[i] == '.' {
+		i++
+		s = i
+		for i < len(raw) && raw[i] >= '0' && raw[i] <= '9' {
+			i++
+		}
+		fpart = raw[s:i]
+	}
+	if i < len(raw) && (raw[i] == 'e' || raw[i] == 'E') {
+		i++
+		var eneg bool
+		if i < len(raw) && (raw[i] == '-' || raw[i] == '+') {
+			eneg = raw[i] == '-'
+			i++
+		}
+		s = i
+		for i < len(raw) && raw[i] >= '0' && raw[i] <= '9' {
+			if i-s >= 9 {
+				// exponent out of range
+				return false, "", 0, false
+			}
+			exp = exp*10 + int(raw[i]-'0')
+			i++
+		}
+		if eneg {
+			exp = -exp
+		}
+	}
+	digits = strings.TrimLeft(ipart+fpart, "0")
+	if digits == "" {
+		return false, "0", exp - len(fpart), true
+	}
+	return neg, digits, exp - len(fpart), true
+}
+
+// numberText returns the text of a numeric value, which is the Raw of a
+// Number or the Str of a String.
+func (t Result) numberText() (string, bool) {
+	switch t.Type {
+	case Number:
+		if len(t.Raw) == 0 {
+			// calculated result
+			return strconv.FormatFloat(t.Num, 'f', -1, 64), true
+		}
+		return t.Raw, true
+	case String:
+		return t.Str, true
+	}
+	return "", false
+}
+
+// BigInt returns an arbitrary-precision integer representation.
+// The ok return is false when the value is not a number, or a string
+// containing a number in the JSON number grammar, that is exactly an integer.
+//
+//	12345678901234567890 -> 12345678901234567890, true
+//	1.5e3                -> 1500, true
+//	1.5                  -> nil, false
+func (t Result) BigInt() (n *big.Int, ok bool) {
+	raw, ok := t.numberText()
+	if !ok {
+		return nil, false
+	}
+	neg, digits, exp, ok := decimalParts(raw)
+	if !ok {
+		return nil, false
+	}
+	if exp < 0 {
+		if -exp >= len(digits) {
+			if digits != "0" {
+				return nil, false
+			}
+		} else {
+			frac := digits[len(digits)+exp:]
+			if strings.Trim(frac, "0") != "" {
+				return nil, false
+			}
+			digits = digits[:len(digits)+exp]
+		}
+	} else if exp > 0 && digits != "0" {
+		if exp > maxDecimalExp {
+			return nil, false
+		}
+		digits += strings.Repeat("0", exp)
+	}
+	n, ok = new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, false
+	}
+	if neg {
+		n.Neg(n)
+	}
+	return n, true
+}
+
+// BigFloat returns an arbitrary-precision floating-point representation.
+// The precision is large enough to hold every significant digit of the
+// original number. The ok return is false when the value is not a number, or a
+// string containing a number in the JSON number grammar.
+func (t Result) BigFloat() (f *big.Float, ok bool) {
+	raw, ok := t.numberText()
+	if !ok {
+		return nil, false
+	}
+	_, digits, _, ok := decimalParts(raw)
+	if !ok {
+		return nil, false
+	}
+	// about 3.33 bits for each decimal digit
+	prec := uint(len(digits))*4 + 64
+	f, _, err := big.ParseFloat(raw, 10, prec, big.ToNearestEven)
+	if err != nil {
+		return nil, false
+	}
+	return f, true
+}
+
+// Decimal returns the exact decimal text of a number, without an exponent.
+// Trailing zeros in the fraction are preserved, also for zero. A number with
+// an exponent beyond ±16384, which is too large to expand, is returned as
+// its original text instead. An empty string is returned when the value is
+// not a number, or a string containing a number in the JSON number grammar.
+//
+//	1.50    -> "1.50"
+//	-2e3    -> "-2000"
+//	12e-4   -> "0.0012"
+//	0.00    -> "0.00"
+//	1e99999 -> "1e99999"
+func (t Result) Decimal() string {
+	raw, ok := t.numberText()
+	if !ok {
+		return ""
+	}
+	neg, digits, exp, ok := decimalParts(raw)
+	if !ok {
+		return ""
+	}
+	if exp > maxDecimalExp || exp < -maxDecimalExp {
+		// too large to expand
+		return raw
+	}
+	var b []byte
+	if neg {
+		b = append(b, '-')
+	}
+	switch {
+	case exp >= 0:
+		b = append(b, digits...)
+		if digits != "0" {
+			for i := 0; i < exp; i++ {
+				b = append(b, '0')
+			}
+		}
+	case -exp < len(digits):
+		b = append(b, digits[:len(digits)+exp]...)
+		b = append(b, '.')
+		b = append(b, digits[len(digits)+exp:]...)
+	default:
+		b = append(b, '0', '.')
+		for i := len(digits); i < -exp; i++ {
+			b = append(b, '0')
+		}
+		b = append(b, digits...)
+	}
+	return string(b)
+}
+
+// Number returns a json.Number representation, which holds the original
+// text of the number. An empty json.Number is returned when the value is not
+// a number, or a string containing a number in the JSON number grammar.
+func (t Result) Number() json.Number {
+	raw, ok := t.numberText()
+	if !ok || !isNumber(raw) {
+		return ""
+	}
+	return json.Number(raw)
+}
+
+// isNumber reports whether s is a number in the JSON number grammar, which
+// has no leading '+' or zeros, and no missing integer or fraction digits.
+func isNumber(s string) bool {
+	if len(s) == 0 || (s[0] != '-' && (s[0] < '0' || s[0] > '9')) {
+		return false
+	}
+	i, ok := validnumber([]byte(s), 1)
+	return ok && i == len(s)
+}
+
 // Time returns a time.Time representation.
+// Unix epoch numbers and RFC3339 strings are detected automatically. See
+// TimeE for details.
 func (t Result) Time() time.Time {
-	res, _ := time.Parse(time.RFC3339, t.String())
+	res, _ := t.TimeE()
+	return res
+}
+
+// TimeLayout returns a time.Time representation using the provided layout.
+// The zero time is returned when the value cannot be parsed.
+func (t Result) TimeLayout(layout string) time.Time {
+	res, _ := t.TimeE(layout)
 	return res
 }
 
+// TimeE returns a time.Time representation, or an error if the value cannot
+// be converted.
+//
+// Numbers are treated as Unix epoch timestamps. The unit is detected from
+// the magnitude of the value:
+//
+//	1700000000           seconds
+//	1700000000000        milliseconds
+//	1700000000000000     microseconds
+//	1700000000000000000  nanoseconds
+//
+// A fraction, such as 1700000000000.5, is in the unit of the integer part.
+// A ConvError wrapping ErrRange is returned when the integer part doesn't
+// fit in an int64.
+//
+// Strings are parsed with each of the provided layouts in order, and the
+// first success is returned. When no layouts are provided, RFC3339 is used.
+// Layouts may also be one of the names "RFC3339", "RFC3339Nano", "RFC1123",
+// "DateTime", "DateOnly", etc., which map to the time package constants.
+//
+// The error is a ConvError, which wraps the error from time.Parse when a
+// string cannot be parsed.
+func (t Result) TimeE(layouts ...string) (time.Time, error) {
+	switch t.Type {
+	case Number:
+		raw, _ := t.numberText()
+		tm, err := epochTime(raw)
+		if err != nil {
+			return time.Time{}, t.convError("TimeE", err)
+		}
+		return tm, nil
+	case String:
+		if len(layouts) == 0 {
+			layouts = []string{time.RFC3339}
+		}
+		tm, err := parseTime(t.Str, layouts)
+		if err != nil {
+			return time.Time{}, t.convError("TimeE", err)
+		}
+		return tm, nil
+	}
+	return time.Time{}, t.convError("TimeE", ErrType)
+}
+
+// timeLayouts are the named layouts that may be used in place of a Go time
+// layout by TimeE and the @date modifier.
+var timeLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"Stamp":       time.Stamp,
+	"StampMilli":  time.StampMilli,
+	"StampMicro":  time.StampMicro,
+	"StampNano":   time.StampNano,
+	"DateTime":    "2006-01-02 15:04:05",
+	"DateOnly":    "2006-01-02",
+	"TimeOnly":    "15:04:05",
+}
+
+// parseTime parses s using the first layout that succeeds.
+func parseTime(s string, layouts []string) (time.Time, error) {
+	var err error
+	for _, layout := range layouts {
+		if named, ok := timeLayouts[layout]; ok {
+			layout = named
+		}
+		var tm time.Time
+		tm, err = time.Parse(layout, s)
+		if err == nil {
+			return tm, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// epochTime converts a raw JSON number to a UTC time, detecting whether the
+// number is in seconds, milliseconds, microseconds or nanoseconds from its
+// integer part. The fraction is in the same unit. ErrRange is returned when
+// the integer part doesn't fit in an int64.
+func epochTime(raw string) (time.Time, error) {
+	neg, digits, exp, ok := decimalParts(raw)
+	if !ok {
+		if isNumber(raw) {
+			// the exponent has too many digits
+			return time.Time{}, ErrRange
+		}
+		return time.Time{}, ErrSyntax
+	}
+	// split the digits into the integer part and the fraction
+	var ipart, fpart string
+	switch {
+	case digits == "0":
+		ipart = "0"
+	case exp >= 0:
+		if len(digits)+exp > 19 {
+			return time.Time{}, ErrRange
+		}
+		ipart = digits + strings.Repeat("0", exp)
+	case -exp < len(digits):
+		ipart, fpart = digits[:len(digits)+exp], digits[len(digits)+exp:]
+	default:
+		// a fraction past a tenth of a nanosecond is rounded away
+		ipart = "0"
+		if zeros := -exp - len(digits); zeros < 10 {
+			fpart = strings.Repeat("0", zeros) + digits
+		}
+	}
+	n, err := strconv.ParseInt(ipart, 10, 64)
+	if err != nil {
+		return time.Time{}, ErrRange
+	}
+	var unit int64 // nanoseconds in a unit
+	switch {
+	case n < 1e11:
+		unit = 1e9
+	case n < 1e14:
+		unit = 1e6
+	case n < 1e17:
+		unit = 1e3
+	default:
+		unit = 1
+	}
+	sec, nsec := n/(1e9/unit), n%(1e9/unit)*unit
+	if fpart != "" {
+		// only the digits down to a nanosecond are used
+		if len(fpart) > 10 {
+			fpart = fpart[:10]
+		}
+		f, _ := strconv.ParseFloat("0."+fpart, 64)
+		nsec += int64(math.Round(f * float64(unit)))
+	}
+	if neg {
+		sec, nsec = -sec, -nsec
+	}
+	return time.Unix(sec, nsec).UTC(), nil
+}
+
 // Array returns back an array of values.
 // If the result represents a null value or is non-existent, then an empty
 // array will be returned.
@@ -264,7 +826,9 @@ func (t Result) ForEach(iterator func(key, value Result) bool) {
 			key.Num = -1
 			break
 		}
-		if json[i] > ' ' {
+		if json[i] == '/' {
+			i = skipComment(json, i) - 1
+		} else if json[i] > ' ' {
 			return
 		}
 	}
@@ -274,6 +838,9 @@ func (t Result) ForEach(iterator func(key, value Result) bool) {
 	var idx int
 	for ; i < len(json); i++ {
 		if obj {
+			if json[i] == '/' {
+				i = skipComment(json, i) - 1
+			}
 			if json[i] != '"' {
 				continue
 			}
@@ -296,6 +863,10 @@ func (t Result) ForEach(iterator func(key, value Result) bool) {
 			if json[i] <= ' ' || json[i] == ',' || json[i] == ':' {
 				continue
 			}
+			if json[i] == '/' {
+				i = skipComment(json, i) - 1
+				continue
+			}
 			break
 		}
 		s := i
@@ -330,13 +901,34 @@ func (t Result) Map() map[string]Result {
 // Get searches result for the specified path.
 // The result should be a JSON array or object.
 func (t Result) Get(path string) Result {
-	r := Get(t.Raw, path)
-	if r.Indexes != nil {
-		for i := 0; i < len(r.Indexes); i++ {
-			r.Indexes[i] += t.Index
+	return t.child(Get(t.Raw, path))
+}
+
+// offsetIndexes adds an offset to the known indexes.
+func offsetIndexes(indexes []int, offset int) {
+	for i := 0; i < len(indexes); i++ {
+		if indexes[i] != 0 {
+			indexes[i] += offset
 		}
-	} else {
+	}
+}
+
+// getLimit is like Get, but with the limits of a search.
+func (t Result) getLimit(path string, l *limiter) Result {
+	return t.child(getLimit(t.Raw, path, l))
+}
+
+// child offsets the indexes of a result from the raw json of t. A result
+// that isn't a slice of the raw json, such as the output of a modifier, has
+// no index.
+func (t Result) child(r Result) Result {
+	if r.Indexes != nil {
+		offsetIndexes(r.Indexes, t.Index)
+	}
+	if sliceAt(t.Raw, r.Index, r.Raw) {
 		r.Index += t.Index
+	} else {
+		r.Index = 0
 	}
 	return r
 }
@@ -362,7 +954,9 @@ func (t Result) arrayOrMap(vc byte, valueize bool) (r arrayOrMapResult) {
 				i++
 				break
 			}
-			if json[i] > ' ' {
+			if json[i] == '/' {
+				i = skipComment(json, i) - 1
+			} else if json[i] > ' ' {
 				goto end
 			}
 		}
@@ -372,7 +966,9 @@ func (t Result) arrayOrMap(vc byte, valueize bool) (r arrayOrMapResult) {
 				i++
 				break
 			}
-			if json[i] > ' ' {
+			if json[i] == '/' {
+				i = skipComment(json, i) - 1
+			} else if json[i] > ' ' {
 				goto end
 			}
 		}
@@ -428,6 +1024,9 @@ func (t Result) arrayOrMap(vc byte, valueize bool) (r arrayOrMapResult) {
 			value.Type = String
 			value.Raw, value.Str = tostr(json[i:])
 			value.Num = 0
+		case '/':
+			i = skipComment(json, i) - 1
+			continue
 		}
 		value.Index = i + t.Index
 
@@ -513,6 +1112,9 @@ func Parse(json string) Result {
 		case '"':
 			value.Type = String
 			value.Raw, value.Str = tostr(json[i:])
+		case '/':
+			i = skipComment(json, i) - 1
+			continue
 		default:
 			return Result{}
 		}
@@ -577,6 +1179,10 @@ func squash(json string) string {
 				if depth == 0 {
 					return json[:i+1]
 				}
+			case '/':
+				if depth > 0 {
+					i = skipComment(json, i) - 1
+				}
 			}
 		}
 	}
@@ -744,7 +1350,7 @@ func parseNumber(json string, i int) (int, string) {
 	i++
 	for ; i < len(json); i++ {
 		if json[i] <= ' ' || json[i] == ',' || json[i] == ']' ||
-			json[i] == '}' {
+			json[i] == '}' || json[i] == '/' {
 			return i, json[s:i]
 		}
 	}
@@ -780,7 +1386,7 @@ type arrayPathResult struct {
 	}
 }
 
-func parseArrayPath(path string) (r arrayPathResult) {
+func parseArrayPath(path string, l *limiter) (r arrayPathResult) {
 	for i := 0; i < len(path); i++ {
 		if path[i] == '|' {
 			r.part = path[:i]
@@ -790,11 +1396,11 @@ func parseArrayPath(path string) (r arrayPathResult) {
 		}
 		if path[i] == '.' {
 			r.part = path[:i]
-			if !r.arrch && i < len(path)-1 && isDotPiperChar(path[i+1:]) {
+			if !r.arrch && i < len(path)-1 && isDotPiperChar(path[i+1:], l) {
 				r.pipe = path[i+1:]
 				r.piped = true
 			} else {
-				r.path = path[i+1:]
+				r.path = afterSep(path, i)
 				r.more = true
 			}
 			return
@@ -804,7 +1410,7 @@ func parseArrayPath(path string) (r arrayPathResult) {
 			if i == 0 && len(path) > 1 {
 				if path[1] == '.' {
 					r.alogok = true
-					r.alogkey = path[2:]
+					r.alogkey = afterSep(path, 1)
 					r.path = path[:1]
 				} else if path[1] == '[' || path[1] == '(' {
 					// query
@@ -960,8 +1566,8 @@ right:
 }
 
 // peek at the next byte and see if it's a '@', '[', or '{'.
-func isDotPiperChar(s string) bool {
-	if DisableModifiers {
+func isDotPiperChar(s string, l *limiter) bool {
+	if l.modifiersDisabled() {
 		return false
 	}
 	c := s[0]
@@ -973,7 +1579,7 @@ func isDotPiperChar(s string) bool {
 				break
 			}
 		}
-		_, ok := modifiers[s[1:i]]
+		_, ok := l.modifier(s[1:i])
 		return ok
 	}
 	return c == '[' || c == '{'
@@ -986,9 +1592,16 @@ type objectPathResult struct {
 	piped bool
 	wild  bool
 	more  bool
+	fold  bool
 }
 
-func parseObjectPath(path string) (r objectPathResult) {
+func parseObjectPath(path string, l *limiter) (r objectPathResult) {
+	if len(path) > 1 && path[0] == '~' && l.foldPrefix() {
+		// case-insensitive component
+		r = parseObjectPath(path[1:], l)
+		r.fold = true
+		return r
+	}
 	for i := 0; i < len(path); i++ {
 		if path[i] == '|' {
 			r.part = path[:i]
@@ -998,11 +1611,11 @@ func parseObjectPath(path string) (r objectPathResult) {
 		}
 		if path[i] == '.' {
 			r.part = path[:i]
-			if i < len(path)-1 && isDotPiperChar(path[i+1:]) {
+			if i < len(path)-1 && isDotPiperChar(path[i+1:], l) {
 				r.pipe = path[i+1:]
 				r.piped = true
 			} else {
-				r.path = path[i+1:]
+				r.path = afterSep(path, i)
 				r.more = true
 			}
 			return
@@ -1028,11 +1641,11 @@ func parseObjectPath(path string) (r objectPathResult) {
 						continue
 					} else if path[i] == '.' {
 						r.part = string(epart)
-						if i < len(path)-1 && isDotPiperChar(path[i+1:]) {
+						if i < len(path)-1 && isDotPiperChar(path[i+1:], l) {
 							r.pipe = path[i+1:]
 							r.piped = true
 						} else {
-							r.path = path[i+1:]
+							r.path = afterSep(path, i)
 							r.more = true
 						}
 						return
@@ -1057,7 +1670,7 @@ func parseObjectPath(path string) (r objectPathResult) {
 }
 
 var vchars = [256]byte{
-	'"': 2, '{': 3, '(': 3, '[': 3, '}': 1, ')': 1, ']': 1,
+	'"': 2, '{': 3, '(': 3, '[': 3, '}': 1, ')': 1, ']': 1, '/': 4,
 }
 
 func parseSquash(json string, i int) (int, string) {
@@ -1188,6 +1801,10 @@ func parseSquash(json string, i int) (int, string) {
 				}
 				break
 			}
+		} else if c == 4 {
+			// '/' a comment
+			i = skipComment(json, i)
+			continue
 		} else {
 			// '{', '[', '(', '}', ']', ')'
 			// open close tokens
@@ -1205,12 +1822,23 @@ func parseSquash(json string, i int) (int, string) {
 func parseObject(c *parseContext, i int, path string) (int, bool) {
 	var pmatch, kesc, vesc, ok, hit bool
 	var key, val string
-	rp := parseObjectPath(path)
+	rp := parseObjectPath(path, c.lim)
 	if !rp.more && rp.piped {
 		c.pipe = rp.pipe
 		c.piped = true
 	}
+	if c.lim != nil && !c.lim.nested(c.depth+1) {
+		return len(c.json), false
+	}
+	fold := c.fold || rp.fold
+	var fpart string
+	if fold && rp.wild {
+		fpart = foldString(rp.part)
+	}
 	for i < len(c.json) {
+		if c.lim != nil && !c.lim.step(1) {
+			return len(c.json), false
+		}
 		for ; i < len(c.json); i++ {
 			if c.json[i] == '"' {
 				// parse_key_string
@@ -1260,21 +1888,39 @@ func parseObject(c *parseContext, i int, path string) (int, bool) {
 			if c.json[i] == '}' {
 				return i + 1, false
 			}
+			if c.json[i] == '/' {
+				i = skipComment(c.json, i) - 1
+			}
 		}
 		if !ok {
 			return i, false
 		}
+		if kesc {
+			key = unescape(key)
+		}
 		if rp.wild {
-			if kesc {
-				pmatch = matchLimit(unescape(key), rp.part)
-			} else {
-				pmatch = matchLimit(key, rp.part)
+			pmatch = matchLimit(key, rp.part)
+			if !pmatch && fold {
+				// an exact match wins over a folded match
+				pmatch = matchLimit(foldString(key), fpart) &&
+					nextKey(c.json, i, rp.part, true) == -1
 			}
 		} else {
-			if kesc {
-				pmatch = rp.part == unescape(key)
-			} else {
-				pmatch = rp.part == key
+			pmatch = rp.part == key
+			if !pmatch && fold {
+				pmatch = strings.EqualFold(rp.part, key) &&
+					nextKey(c.json, i, rp.part, false) == -1
+			}
+			if pmatch && c.dupes != DuplicateFirst {
+				if j := nextDuplicate(c.json, i, rp.part); j != -1 {
+					if c.dupes == DuplicateError {
+						c.lim.fail(ErrDuplicateKey)
+						return len(c.json), false
+					}
+					for ; j != -1; j = nextDuplicate(c.json, j, rp.part) {
+						i = j
+					}
+				}
 			}
 		}
 		hit = pmatch && !rp.more
@@ -1283,6 +1929,9 @@ func parseObject(c *parseContext, i int, path string) (int, bool) {
 			switch c.json[i] {
 			default:
 				continue
+			case '/':
+				i = skipComment(c.json, i) - 1
+				continue
 			case '"':
 				i++
 				i, val, vesc, ok = parseString(c.json, i)
@@ -1301,7 +1950,12 @@ func parseObject(c *parseContext, i int, path string) (int, bool) {
 				}
 			case '{':
 				if pmatch && !hit {
+					if isDescent(rp.path) {
+						return c.descend(i, rp.path), true
+					}
+					c.depth++
 					i, hit = parseObject(c, i+1, rp.path)
+					c.depth--
 					if hit {
 						return i, true
 					}
@@ -1315,7 +1969,12 @@ func parseObject(c *parseContext, i int, path string) (int, bool) {
 				}
 			case '[':
 				if pmatch && !hit {
+					if isDescent(rp.path) {
+						return c.descend(i, rp.path), true
+					}
+					c.depth++
 					i, hit = parseArray(c, i+1, rp.path)
+					c.depth--
 					if hit {
 						return i, true
 					}
@@ -1413,6 +2072,80 @@ func nullish(t Result) bool {
 	return t.Type == Null
 }
 
+// queryTime parses a string that looks like an RFC3339 timestamp or a date
+// in the form "2006-01-02". Times without a zone are treated as UTC.
+func queryTime(s string) (time.Time, bool) {
+	if len(s) < 10 || s[4] != '-' || s[7] != '-' {
+		return time.Time{}, false
+	}
+	for _, c := range []byte(s[:4] + s[5:7] + s[8:10]) {
+		if c < '0' || c > '9' {
+			return time.Time{}, false
+		}
+	}
+	for _, layout := range []string{
+		time.RFC3339, "2006-01-02T15:04:05", "2006-01-02",
+	} {
+		if tm, err := time.Parse(layout, s); err == nil {
+			return tm, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// compareIntegers compares two integer literals of any size. The ok return is
+// false when either input is not an integer literal.
+func compareIntegers(a, b string) (cmp int, ok bool) {
+	aneg, adigits, ok := splitInteger(a)
+	if !ok {
+		return 0, false
+	}
+	bneg, bdigits, ok := splitInteger(b)
+	if !ok {
+		return 0, false
+	}
+	if aneg != bneg {
+		if aneg {
+			return -1, true
+		}
+		return 1, true
+	}
+	switch {
+	case len(adigits) < len(bdigits):
+		cmp = -1
+	case len(adigits) > len(bdigits):
+		cmp = 1
+	default:
+		cmp = strings.Compare(adigits, bdigits)
+	}
+	if aneg {
+		cmp = -cmp
+	}
+	return cmp, true
+}
+
+// splitInteger returns the sign and digits, without leading zeros, of an
+// integer literal. Negative zero is returned as positive.
+func splitInteger(s string) (neg bool, digits string, ok bool) {
+	if len(s) > 0 && s[0] == '-' {
+		neg = true
+		s = s[1:]
+	}
+	if len(s) == 0 {
+		return false, "", false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false, "", false
+		}
+	}
+	digits = strings.TrimLeft(s, "0")
+	if digits == "" {
+		return false, "0", true
+	}
+	return neg, digits, true
+}
+
 func queryMatches(rp *arrayPathResult, value Result) bool {
 	rpv := rp.query.value
 	if len(rpv) > 0 {
@@ -1455,6 +2188,24 @@ func queryMatches(rp *arrayPathResult, value Result) bool {
 	}
 	switch value.Type {
 	case String:
+		switch rp.query.op {
+		case "<", "<=", ">", ">=":
+			// timestamps are compared chronologically
+			if a, ok := queryTime(value.Str); ok {
+				if b, ok := queryTime(rpv); ok {
+					switch rp.query.op {
+					case "<":
+						return a.Before(b)
+					case "<=":
+						return !a.After(b)
+					case ">":
+						return a.After(b)
+					case ">=":
+						return !a.Before(b)
+					}
+				}
+			}
+		}
 		switch rp.query.op {
 		case "=":
 			return value.Str == rpv
@@ -1474,6 +2225,24 @@ func queryMatches(rp *arrayPathResult, value Result) bool {
 			return !matchLimit(value.Str, rpv)
 		}
 	case Number:
+		// integers are compared exactly by their text, which avoids the
+		// precision loss of float64 for values beyond 2^53.
+		if cmp, ok := compareIntegers(value.Raw, rpv); ok {
+			switch rp.query.op {
+			case "=":
+				return cmp == 0
+			case "!=":
+				return cmp != 0
+			case "<":
+				return cmp < 0
+			case "<=":
+				return cmp <= 0
+			case ">":
+				return cmp > 0
+			case ">=":
+				return cmp >= 0
+			}
+		}
 		rpvn, _ := strconv.ParseFloat(rpv, 64)
 		switch rp.query.op {
 		case "=":
@@ -1511,6 +2280,16 @@ func queryMatches(rp *arrayPathResult, value Result) bool {
 		case "<=":
 			return true
 		}
+	case JSON:
+		// object and array literals are compared structurally
+		if len(rpv) > 0 && (rpv[0] == '{' || rpv[0] == '[') {
+			switch rp.query.op {
+			case "=":
+				return value.Equal(Parse(rpv))
+			case "!=":
+				return !value.Equal(Parse(rpv))
+			}
+		}
 	}
 	return false
 }
@@ -1522,7 +2301,7 @@ func parseArray(c *parseContext, i int, path string) (int, bool) {
 	var partidx int
 	var multires []byte
 	var queryIndexes []int
-	rp := parseArrayPath(path)
+	rp := parseArrayPath(path, c.lim)
 	if !rp.arrch {
 		n, ok := parseUint(rp.part)
 		if !ok {
@@ -1547,8 +2326,13 @@ func parseArray(c *parseContext, i int, path string) (int, bool) {
 		fillIndex(c.json, &tmp)
 		parentIndex := tmp.value.Index
 		var res Result
-		if qval.Type == JSON {
-			res = qval.Get(rp.query.path)
+		if qval.Type == JSON && rp.query.path == "" &&
+			len(rp.query.value) > 0 &&
+			(rp.query.value[0] == '{' || rp.query.value[0] == '[') {
+			// compare the element itself with an object or array literal
+			res = qval
+		} else if qval.Type == JSON {
+			res = c.get(qval, rp.query.path)
 		} else {
 			if rp.query.path != "" {
 				return false
@@ -1563,7 +2347,7 @@ func parseArray(c *parseContext, i int, path string) (int, bool) {
 					c.pipe = right
 					c.piped = true
 				}
-				res = qval.Get(rp.path)
+				res = c.get(qval, rp.path)
 			} else {
 				res = qval
 			}
@@ -1577,7 +2361,11 @@ func parseArray(c *parseContext, i int, path string) (int, bool) {
 						multires = append(multires, ',')
 					}
 					multires = append(multires, raw...)
-					queryIndexes = append(queryIndexes, res.Index+parentIndex)
+					if !c.lim.emit(len(raw) + 1) {
+						return true
+					}
+					res.Index += parentIndex
+					queryIndexes = append(queryIndexes, rawIndex(c.json, res))
 				}
 			} else {
 				c.value = res
@@ -1586,7 +2374,13 @@ func parseArray(c *parseContext, i int, path string) (int, bool) {
 		}
 		return false
 	}
+	if c.lim != nil && !c.lim.nested(c.depth+1) {
+		return len(c.json) + 1, false
+	}
 	for i < len(c.json)+1 {
+		if c.lim != nil && !c.lim.step(1) {
+			return len(c.json) + 1, false
+		}
 		if !rp.arrch {
 			pmatch = partidx == h
 			hit = pmatch && !rp.more
@@ -1608,6 +2402,9 @@ func parseArray(c *parseContext, i int, path string) (int, bool) {
 			switch ch {
 			default:
 				continue
+			case '/':
+				i = skipComment(c.json, i) - 1
+				continue
 			case '"':
 				i++
 				i, val, vesc, ok = parseString(c.json, i)
@@ -1641,7 +2438,12 @@ func parseArray(c *parseContext, i int, path string) (int, bool) {
 				}
 			case '{':
 				if pmatch && !hit {
+					if isDescent(rp.path) {
+						return c.descend(i, rp.path), true
+					}
+					c.depth++
 					i, hit = parseObject(c, i+1, rp.path)
+					c.depth--
 					if hit {
 						if rp.alogok {
 							break
@@ -1665,7 +2467,12 @@ func parseArray(c *parseContext, i int, path string) (int, bool) {
 				}
 			case '[':
 				if pmatch && !hit {
+					if isDescent(rp.path) {
+						return c.descend(i, rp.path), true
+					}
+					c.depth++
 					i, hit = parseArray(c, i+1, rp.path)
+					c.depth--
 					if hit {
 						if rp.alogok {
 							break
@@ -1743,13 +2550,16 @@ func parseArray(c *parseContext, i int, path string) (int, bool) {
 								case ' ', '\t', '\r', '\n':
 									idx++
 									continue
+								case '/':
+									idx = skipComment(c.json, idx)
+									continue
 								}
 								break
 							}
 							if idx < len(c.json) && c.json[idx] != ']' {
 								_, res, ok := parseAny(c.json, idx, true)
 								if ok {
-									res := res.Get(rp.alogkey)
+									res := c.get(res, rp.alogkey)
 									if res.Exists() {
 										if k > 0 {
 											jsons = append(jsons, ',')
@@ -1759,7 +2569,11 @@ func parseArray(c *parseContext, i int, path string) (int, bool) {
 											raw = res.String()
 										}
 										jsons = append(jsons, []byte(raw)...)
-										indexes = append(indexes, res.Index)
+										if !c.lim.emit(len(raw) + 1) {
+											return len(c.json) + 1, false
+										}
+										indexes = append(indexes,
+											rawIndex(c.json, res))
 										k++
 									}
 								}
@@ -2101,6 +2915,22 @@ type parseContext struct {
 	piped bool
 	calcd bool
 	lines bool
+	dupes DuplicatePolicy
+	fold  bool
+	lim   *limiter
+	depth int
+}
+
+// get searches a value that was found while parsing, such as an element of
+// a query, at the current depth.
+func (c *parseContext) get(t Result, path string) Result {
+	if c.lim == nil {
+		return t.child(getPath(t.Raw, path, nil))
+	}
+	c.lim.depth += c.depth
+	res := t.getLimit(path, c.lim)
+	c.lim.depth -= c.depth
+	return res
 }
 
 // Get searches json for the specified path.
@@ -2137,90 +2967,87 @@ type parseContext struct {
 // If you are consuming JSON from an unpredictable source then you may want to
 // use the Valid function first.
 func Get(json, path string) Result {
+	return defaultEngine.Get(json, path)
+}
+
+// getLimit is like Get, but with the limits of a search.
+func getLimit(json, path string, l *limiter) Result {
+	if l == nil {
+		return getPath(json, path, nil)
+	}
+	if !l.rooted {
+		l.root, l.rooted = json, true
+	}
+	var res Result
+	if l.enter() {
+		res = getPath(json, path, l)
+	}
+	l.leave()
+	return res
+}
+
+func getPath(json, path string, l *limiter) Result {
 	if len(path) > 1 {
-		if (path[0] == '@' && !DisableModifiers) || path[0] == '!' {
+		if (path[0] == '@' && !l.modifiersDisabled()) ||
+			(path[0] == '!' && !l.literalsDisabled()) {
 			// possible modifier
 			var ok bool
 			var npath string
 			var rjson string
-			if path[0] == '@' && !DisableModifiers {
-				npath, rjson, ok = execModifier(json, path)
-			} else if path[0] == '!' {
+			var indexes []int
+			if path[0] == '@' {
+				npath, rjson, indexes, ok = execModifier(json, path, l)
+			} else {
 				npath, rjson, ok = execStatic(json, path)
+				if ok {
+					l.emit(len(rjson))
+				}
 			}
 			if ok {
 				path = npath
-				if len(path) > 0 && (path[0] == '|' || path[0] == '.') {
-					res := Get(rjson, path[1:])
-					res.Index = 0
-					res.Indexes = nil
+				res := Parse(rjson)
+				rest := len(path) > 0 && (path[0] == '|' || path[0] == '.')
+				if rest {
+					res = getLimit(rjson, afterSep(path, 0), l)
+				}
+				if base := sliceIndex(json, rjson); base >= 0 {
+					// the modifier returned a slice of its input
+					return Result{Raw: rjson, Index: base}.child(res)
+				}
+				if rest && indexes != nil {
+					// the elements of the output are values of the input
+					res = Result{Type: JSON, Raw: rjson,
+						Indexes: indexes}.remap(res)
+					if rawAt(json, res.Index, res.Raw) {
+						// a slice of the input, like any other result
+						res.Raw = json[res.Index : res.Index+len(res.Raw)]
+					}
 					return res
 				}
-				return Parse(rjson)
+				res.Index = 0
+				res.Indexes = nil
+				if !rest {
+					res.Indexes = indexes
+				}
+				return res
 			}
 		}
 		if path[0] == '[' || path[0] == '{' {
 			// using a subselector path
-			kind := path[0]
+			var res Result
 			var ok bool
-			var subs []subSelector
-			subs, path, ok = parseSubSelectors(path)
+			res, _, path, ok = getMultipath(json, path, l)
 			if ok {
-				if len(path) == 0 || (path[0] == '|' || path[0] == '.') {
-					var b []byte
-					b = append(b, kind)
-					var i int
-					for _, sub := range subs {
-						res := Get(json, sub.path)
-						if res.Exists() {
-							if i > 0 {
-								b = append(b, ',')
-							}
-							if kind == '{' {
-								if len(sub.name) > 0 {
-									if sub.name[0] == '"' && Valid(sub.name) {
-										b = append(b, sub.name...)
-									} else {
-										b = AppendJSONString(b, sub.name)
-									}
-								} else {
-									last := nameOfLast(sub.path)
-									if isSimpleName(last) {
-										b = AppendJSONString(b, last)
-									} else {
-										b = AppendJSONString(b, "_")
-									}
-								}
-								b = append(b, ':')
-							}
-							var raw string
-							if len(res.Raw) == 0 {
-								raw = res.String()
-								if len(raw) == 0 {
-									raw = "null"
-								}
-							} else {
-								raw = res.Raw
-							}
-							b = append(b, raw...)
-							i++
-						}
-					}
-					b = append(b, kind+2)
-					var res Result
-					res.Raw = string(b)
-					res.Type = JSON
-					if len(path) > 0 {
-						res = res.Get(path[1:])
-					}
-					res.Index = 0
-					return res
-				}
+				return res
 			}
 		}
 	}
+	if isDescent(path) {
+		return getDescent(json, path[2:], l)
+	}
 	var i int
-	var c = &parseContext{json: json}
+	var c = &parseContext{json: json, dupes: l.duplicateKeys(),
+		fold: l.caseInsensitive(), lim: l}
 	if len(path) >= 2 && path[0] == '.' && path[1] == '.' {
 		c.lines = true
 		parseArray(c, 0, path[2:])
@@ -2236,21 +3063,119 @@ func Get(json, path string) Result {
 				parseArray(c, i, path)
 				break
 			}
+			if c.json[i] == '/' {
+				i = skipComment(c.json, i) - 1
+			}
 		}
 	}
+	fillIndex(json, c)
 	if c.piped {
-		res := c.value.Get(c.pipe)
-		res.Index = 0
-		return res
+		res := getLimit(c.value.Raw, c.pipe, l)
+		if rawAt(json, c.value.Index, c.value.Raw) {
+			return c.value.child(res)
+		}
+		// the value before the pipe was computed
+		return c.value.remap(res)
 	}
-	fillIndex(json, c)
 	return c.value
 }
 
+// getMultipath searches json for a path that starts with a multipath, and
+// also returns the slices of json in the result. The path after the
+// multipath is returned when it doesn't follow a separator, or the multipath
+// can't be parsed.
+func getMultipath(json, path string, l *limiter) (Result, []span, string,
+	bool,
+) {
+	kind := path[0]
+	subs, path, ok := parseSubSelectors(path)
+	if !ok || (len(path) > 0 && path[0] != '|' && path[0] != '.') {
+		return Result{}, nil, path, false
+	}
+	var b []byte
+	var indexes []int
+	var spans []span
+	b = append(b, kind)
+	var i int
+	for _, sub := range subs {
+		res, rspans := getSpans(json, sub.path, l)
+		if res.Exists() {
+			indexes = append(indexes, rawIndex(json, res))
+			if i > 0 {
+				b = append(b, ',')
+			}
+			if kind == '{' {
+				if len(sub.name) > 0 {
+					if sub.name[0] == '"' && Valid(sub.name) {
+						b = append(b, sub.name...)
+					} else {
+						b = AppendJSONString(b, sub.name)
+					}
+				} else {
+					last := nameOfLast(sub.path)
+					if isSimpleName(last) {
+						b = AppendJSONString(b, last)
+					} else {
+						b = AppendJSONString(b, "_")
+					}
+				}
+				b = append(b, ':')
+			}
+			var raw string
+			if len(res.Raw) == 0 {
+				raw = res.String()
+				if len(raw) == 0 {
+					raw = "null"
+				}
+			} else {
+				raw = res.Raw
+			}
+			spans = appendSpans(spans, json, res, rspans, len(b))
+			b = append(b, raw...)
+			i++
+		}
+	}
+	b = append(b, kind+2)
+	if !l.emit(len(b)) {
+		return Result{}, nil, path, true
+	}
+	var res Result
+	res.Raw = string(b)
+	res.Type = JSON
+	res.Indexes = indexes
+	if len(path) > 0 {
+		res, spans = remapSpans(res.Raw, spans,
+			getLimit(res.Raw, afterSep(path, 0), l))
+	}
+	return res, spans, path, true
+}
+
+// getSpans is like getLimit, but also returns the slices of json in a
+// computed result, which are found for a multipath.
+func getSpans(json, path string, l *limiter) (Result, []span) {
+	if len(path) < 2 || (path[0] != '[' && path[0] != '{') {
+		return getLimit(json, path, l), nil
+	}
+	if l != nil {
+		if !l.rooted {
+			l.root, l.rooted = json, true
+		}
+		defer l.leave()
+		if !l.enter() {
+			return Result{}, nil
+		}
+	}
+	res, spans, _, ok := getMultipath(json, path, l)
+	if !ok {
+		return getPath(json, path, l), nil
+	}
+	return res, spans
+}
+
 // GetBytes searches json for the specified path.
 // If working with bytes, this method preferred over Get(string(data), path)
 func GetBytes(json []byte, path string) Result {
-	return getBytes(json, path)
+	return defaultEngine.GetBytes(json, path)
 }
 
 // runeit returns the rune from the the \uXXXX
@@ -2342,6 +3267,186 @@ func (t Result) Less(token Result, caseSensitive bool) bool {
 	return t.Raw < token.Raw
 }
 
+// Equal returns true if two results hold the same json value. Objects are
+// equal when they have the same members in any order, and whitespace, string
+// escapes and number spelling are ignored, such that {"a":1.0,"b":"A"}
+// is equal to {"b":"A","a":1}. For duplicate object keys, the first member
+// is used, as with Get.
+func (t Result) Equal(other Result) bool {
+	return t.Compare(other) == 0
+}
+
+// Compare returns an integer comparing two results. The result will be 0 if
+// t and other are equal, -1 if t is less than other, and +1 if t is greater
+// than other.
+//
+// Results of different types are ordered as in Less, with arrays before
+// objects and non-existent results before everything else:
+//
+//	non-existent < Null < False < Number < String < True < Array < Object
+//
+// Numbers are compared exactly by their decimal value, strings are compared
+// case sensitively, arrays are compared element by element, and objects are
+// compared member by member in key order.
+func (t Result) Compare(other Result) int {
+	ta, oa := compareRank(t), compareRank(other)
+	if ta != oa {
+		if ta < oa {
+			return -1
+		}
+		return 1
+	}
+	switch t.Type {
+	case Number:
+		return compareNumbers(t, other)
+	case String:
+		return strings.Compare(t.Str, other.Str)
+	case JSON:
+		if t.IsArray() {
+			return compareArrays(t, other)
+		}
+		return compareObjects(t, other)
+	}
+	return 0
+}
+
+// compareRank returns the position of the result type in the Compare order.
+func compareRank(t Result) int {
+	if !t.Exists() {
+		return -1
+	}
+	if t.Type == JSON && t.IsObject() {
+		return int(JSON) + 1
+	}
+	return int(t.Type)
+}
+
+func compareNumbers(a, b Result) int {
+	araw, _ := a.numberText()
+	braw, _ := b.numberText()
+	aneg, adigits, aexp, aok := decimalParts(araw)
+	bneg, bdigits, bexp, bok := decimalParts(braw)
+	if !aok || !bok {
+		// NaN, Inf, or malformed numbers
+		switch {
+		case a.Num < b.Num:
+			return -1
+		case a.Num > b.Num:
+			return 1
+		}
+		return 0
+	}
+	if aneg != bneg {
+		if aneg {
+			return -1
+		}
+		return 1
+	}
+	cmp := compareDecimals(adigits, aexp, bdigits, bexp)
+	if aneg {
+		cmp = -cmp
+	}
+	return cmp
+}
+
+// compareDecimals compares the magnitudes of two numbers that have been
+// split by decimalParts.
+func compareDecimals(adigits string, aexp int, bdigits string, bexp int) int {
+	if adigits == "0" || bdigits == "0" {
+		switch {
+		case adigits == bdigits:
+			return 0
+		case adigits == "0":
+			return -1
+		}
+		return 1
+	}
+	// remove trailing zeros so that 1.0 and 1 have the same digits
+	for len(adigits) > 1 && adigits[len(adigits)-1] == '0' {
+		adigits = adigits[:len(adigits)-1]
+		aexp++
+	}
+	for len(bdigits) > 1 && bdigits[len(bdigits)-1] == '0' {
+		bdigits = bdigits[:len(bdigits)-1]
+		bexp++
+	}
+	// compare the position of the leading digit
+	if amag, bmag := len(adigits)+aexp, len(bdigits)+bexp; amag != bmag {
+		if amag < bmag {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(adigits, bdigits)
+}
+
+func compareArrays(a, b Result) int {
+	avals, bvals := a.Array(), b.Array()
+	for i := 0; i < len(avals) && i < len(bvals); i++ {
+		if cmp := avals[i].Compare(bvals[i]); cmp != 0 {
+			return cmp
+		}
+	}
+	switch {
+	case len(avals) < len(bvals):
+		return -1
+	case len(avals) > len(bvals):
+		return 1
+	}
+	return 0
+}
+
+// sortedMembers returns the keys and values of an object in key order. Only
+// the first member of duplicate keys is kept.
+func sortedMembers(obj Result) []Result {
+	var kvs []Result
+	seen := make(map[string]bool)
+	obj.ForEach(func(key, value Result) bool {
+		if !seen[key.Str] {
+			seen[key.Str] = true
+			kvs = append(kvs, key, value)
+		}
+		return true
+	})
+	sort.Sort(byKey(kvs))
+	return kvs
+}
+
+// byKey sorts a slice of alternating keys and values by key.
+type byKey []Result
+
+func (kvs byKey) Len() int {
+	return len(kvs) / 2
+}
+
+func (kvs byKey) Less(i, j int) bool {
+	return kvs[i*2].Str < kvs[j*2].Str
+}
+
+func (kvs byKey) Swap(i, j int) {
+	kvs[i*2], kvs[j*2] = kvs[j*2], kvs[i*2]
+	kvs[i*2+1], kvs[j*2+1] = kvs[j*2+1], kvs[i*2+1]
+}
+
+func compareObjects(a, b Result) int {
+	akvs, bkvs := sortedMembers(a), sortedMembers(b)
+	for i := 0; i < len(akvs) && i < len(bkvs); i += 2 {
+		if cmp := strings.Compare(akvs[i].Str, bkvs[i].Str); cmp != 0 {
+			return cmp
+		}
+		if cmp := akvs[i+1].Compare(bkvs[i+1]); cmp != 0 {
+			return cmp
+		}
+	}
+	switch {
+	case len(akvs) < len(bkvs):
+		return -1
+	case len(akvs) > len(bkvs):
+		return 1
+	}
+	return 0
+}
+
 func stringLessInsensitive(a, b string) bool {
 	for i := 0; i < len(a) && i < len(b); i++ {
 		if a[i] >= 'A' && a[i] <= 'Z' {
@@ -2402,6 +3507,9 @@ func parseAny(json string, i int, hit bool) (int, Result, bool) {
 		}
 		var num bool
 		switch json[i] {
+		case '/':
+			i = skipComment(json, i) - 1
+			continue
 		case '"':
 			i++
 			var vesc bool
@@ -2461,11 +3569,7 @@ func parseAny(json string, i int, hit bool) (int, Result, bool) {
 // The return value is a Result array where the number of items
 // will be equal to the number of input paths.
 func GetMany(json string, path ...string) []Result {
-	res := make([]Result, len(path))
-	for i, path := range path {
-		res[i] = Get(json, path)
-	}
-	return res
+	return defaultEngine.GetMany(json, path...)
 }
 
 // GetManyBytes searches json for the multiple paths.
@@ -2474,16 +3578,16 @@ func GetMany(json string, path ...string) []Result {
 func GetManyBytes(json []byte, path ...string) []Result {
 	res := make([]Result, len(path))
 	for i, path := range path {
-		res[i] = GetBytes(json, path)
+		res[i] = defaultEngine.GetBytes(json, path)
 	}
 	return res
 }
 
-func validpayload(data []byte, i int) (outi int, ok bool) {
+func validpayload(data []byte, i int, jsonc bool) (outi int, ok bool) {
 	for ; i < len(data); i++ {
 		switch data[i] {
 		default:
-			i, ok = validany(data, i)
+			i, ok = validany(data, i, jsonc)
 			if !ok {
 				return i, false
 			}
@@ -2493,26 +3597,41 @@ func validpayload(data []byte, i int) (outi int, ok bool) {
 					return i, false
 				case ' ', '\t', '\n', '\r':
 					continue
+				case '/':
+					if i, ok = validcomment(data, i, jsonc); !ok {
+						return i, false
+					}
+					i--
 				}
 			}
 			return i, true
 		case ' ', '\t', '\n', '\r':
 			continue
+		case '/':
+			if i, ok = validcomment(data, i, jsonc); !ok {
+				return i, false
+			}
+			i--
 		}
 	}
 	return i, false
 }
-func validany(data []byte, i int) (outi int, ok bool) {
+func validany(data []byte, i int, jsonc bool) (outi int, ok bool) {
 	for ; i < len(data); i++ {
 		switch data[i] {
 		default:
 			return i, false
 		case ' ', '\t', '\n', '\r':
 			continue
+		case '/':
+			if i, ok = validcomment(data, i, jsonc); !ok {
+				return i, false
+			}
+			i--
 		case '{':
-			return validobject(data, i+1)
+			return validobject(data, i+1, jsonc)
 		case '[':
-			return validarray(data, i+1)
+			return validarray(data, i+1, jsonc)
 		case '"':
 			return validstring(data, i+1)
 		case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
@@ -2527,13 +3646,18 @@ func validany(data []byte, i int) (outi int, ok bool) {
 	}
 	return i, false
 }
-func validobject(data []byte, i int) (outi int, ok bool) {
+func validobject(data []byte, i int, jsonc bool) (outi int, ok bool) {
 	for ; i < len(data); i++ {
 		switch data[i] {
 		default:
 			return i, false
 		case ' ', '\t', '\n', '\r':
 			continue
+		case '/':
+			if i, ok = validcomment(data, i, jsonc); !ok {
+				return i, false
+			}
+			i--
 		case '}':
 			return i + 1, true
 		case '"':
@@ -2541,13 +3665,13 @@ func validobject(data []byte, i int) (outi int, ok bool) {
 			if i, ok = validstring(data, i+1); !ok {
 				return i, false
 			}
-			if i, ok = validcolon(data, i); !ok {
+			if i, ok = validcolon(data, i, jsonc); !ok {
 				return i, false
 			}
-			if i, ok = validany(data, i); !ok {
+			if i, ok = validany(data, i, jsonc); !ok {
 				return i, false
 			}
-			if i, ok = validcomma(data, i, '}'); !ok {
+			if i, ok = validcomma(data, i, '}', jsonc); !ok {
 				return i, false
 			}
 			if data[i] == '}' {
@@ -2560,6 +3684,17 @@ func validobject(data []byte, i int) (outi int, ok bool) {
 					return i, false
 				case ' ', '\t', '\n', '\r':
 					continue
+				case '/':
+					if i, ok = validcomment(data, i, jsonc); !ok {
+						return i, false
+					}
+					i--
+				case '}':
+					if !jsonc {
+						return i, false
+					}
+					// a trailing comma
+					return i + 1, true
 				case '"':
 					goto key
 				}
@@ -2569,26 +3704,36 @@ func validobject(data []byte, i int) (outi int, ok bool) {
 	}
 	return i, false
 }
-func validcolon(data []byte, i int) (outi int, ok bool) {
+func validcolon(data []byte, i int, jsonc bool) (outi int, ok bool) {
 	for ; i < len(data); i++ {
 		switch data[i] {
 		default:
 			return i, false
 		case ' ', '\t', '\n', '\r':
 			continue
+		case '/':
+			if i, ok = validcomment(data, i, jsonc); !ok {
+				return i, false
+			}
+			i--
 		case ':':
 			return i + 1, true
 		}
 	}
 	return i, false
 }
-func validcomma(data []byte, i int, end byte) (outi int, ok bool) {
+func validcomma(data []byte, i int, end byte, jsonc bool) (outi int, ok bool) {
 	for ; i < len(data); i++ {
 		switch data[i] {
 		default:
 			return i, false
 		case ' ', '\t', '\n', '\r':
 			continue
+		case '/':
+			if i, ok = validcomment(data, i, jsonc); !ok {
+				return i, false
+			}
+			i--
 		case ',':
 			return i, true
 		case end:
@@ -2597,29 +3742,64 @@ func validcomma(data []byte, i int, end byte) (outi int, ok bool) {
 	}
 	return i, false
 }
-func validarray(data []byte, i int) (outi int, ok bool) {
+func validarray(data []byte, i int, jsonc bool) (outi int, ok bool) {
 	for ; i < len(data); i++ {
 		switch data[i] {
 		default:
 			for ; i < len(data); i++ {
-				if i, ok = validany(data, i); !ok {
+				if i, ok = validany(data, i, jsonc); !ok {
 					return i, false
 				}
-				if i, ok = validcomma(data, i, ']'); !ok {
+				if i, ok = validcomma(data, i, ']', jsonc); !ok {
 					return i, false
 				}
 				if data[i] == ']' {
 					return i + 1, true
 				}
+				if jsonc {
+					// a trailing comma
+					j, _ := validcomma(data, i+1, ']', jsonc)
+					if j < len(data) && data[j] == ']' {
+						return j + 1, true
+					}
+				}
 			}
 		case ' ', '\t', '\n', '\r':
 			continue
+		case '/':
+			if i, ok = validcomment(data, i, jsonc); !ok {
+				return i, false
+			}
+			i--
 		case ']':
 			return i + 1, true
 		}
 	}
 	return i, false
 }
+
+// validcomment validates the "//" or "/* */" comment at data[i] and returns
+// the position after it. Comments are only valid in JSONC.
+func validcomment(data []byte, i int, jsonc bool) (outi int, ok bool) {
+	if !jsonc || i+1 == len(data) {
+		return i, false
+	}
+	switch data[i+1] {
+	case '/':
+		for i += 2; i < len(data) && data[i] != '\n'; i++ {
+		}
+		return i, true
+	case '*':
+		for i += 2; i+1 < len(data); i++ {
+			if data[i] == '*' && data[i+1] == '/' {
+				return i + 2, true
+			}
+		}
+		// unterminated
+		return len(data), false
+	}
+	return i, false
+}
 func validstring(data []byte, i int) (outi int, ok bool) {
 	for ; i < len(data); i++ {
 		if data[i] < ' ' {
@@ -2756,7 +3936,7 @@ func validnull(data []byte, i int) (outi int, ok bool) {
 //	}
 //	value := gjson.Get(json, "name.last")
 func Valid(json string) bool {
-	_, ok := validpayload(stringBytes(json), 0)
+	_, ok := validpayload(stringBytes(json), 0, false)
 	return ok
 }
 
@@ -2769,26 +3949,44 @@ func Valid(json string) bool {
 //
 // If working with bytes, this method preferred over ValidBytes(string(data))
 func ValidBytes(json []byte) bool {
-	_, ok := validpayload(json, 0)
+	_, ok := validpayload(json, 0, false)
 	return ok
 }
 
 func parseUint(s string) (n uint64, ok bool) {
+	n, ok, _ = parseUintRange(s)
+	return n, ok
+}
+
+// parseUintRange is like parseUint and also reports when the number does not
+// fit in an uint64, in which case the returned number has wrapped around.
+func parseUintRange(s string) (n uint64, ok, overflow bool) {
 	var i int
 	if i == len(s) {
-		return 0, false
+		return 0, false, false
 	}
 	for ; i < len(s); i++ {
 		if s[i] >= '0' && s[i] <= '9' {
-			n = n*10 + uint64(s[i]-'0')
+			d := uint64(s[i] - '0')
+			if n > (math.MaxUint64-d)/10 {
+				overflow = true
+			}
+			n = n*10 + d
 		} else {
-			return 0, false
+			return 0, false, false
 		}
 	}
-	return n, true
+	return n, true, overflow
 }
 
 func parseInt(s string) (n int64, ok bool) {
+	n, ok, _ = parseIntRange(s)
+	return n, ok
+}
+
+// parseIntRange is like parseInt and also reports when the number does not
+// fit in an int64, in which case the returned number has wrapped around.
+func parseIntRange(s string) (n int64, ok, overflow bool) {
 	var i int
 	var sign bool
 	if len(s) > 0 && s[0] == '-' {
@@ -2796,19 +3994,32 @@ func parseInt(s string) (n int64, ok bool) {
 		i++
 	}
 	if i == len(s) {
-		return 0, false
+		return 0, false, false
 	}
+	// the magnitude, which may be one more than math.MaxInt64 when negative
+	var u uint64
 	for ; i < len(s); i++ {
 		if s[i] >= '0' && s[i] <= '9' {
+			d := uint64(s[i] - '0')
+			if u > (math.MaxUint64-d)/10 {
+				overflow = true
+			}
+			u = u*10 + d
 			n = n*10 + int64(s[i]-'0')
 		} else {
-			return 0, false
+			return 0, false, false
 		}
 	}
 	if sign {
-		return n * -1, true
+		if u > 1<<63 {
+			overflow = true
+		}
+		return n * -1, true, overflow
 	}
-	return n, true
+	if u > math.MaxInt64 {
+		overflow = true
+	}
+	return n, true, overflow
 }
 
 // safeInt validates a given JSON number
@@ -2855,8 +4066,12 @@ func execStatic(json, path string) (pathOut, res string, ok bool) {
 }
 
 // execModifier parses the path to find a matching modifier function.
-// The input expects that the path already starts with a '@'
-func execModifier(json, path string) (pathOut, res string, ok bool) {
+// The input expects that the path already starts with a '@'. The indexes are
+// where the elements of the output are in json, for the built-in modifiers
+// that keep the values of their input, or nil.
+func execModifier(json, path string, l *limiter) (pathOut, res string,
+	indexes []int, ok bool,
+) {
 	name := path[1:]
 	var hasArgs bool
 	for i := 1; i < len(path); i++ {
@@ -2877,10 +4092,10 @@ func execModifier(json, path string) (pathOut, res string, ok bool) {
 			break
 		}
 	}
-	if fn, ok := modifiers[name]; ok {
+	if fn, ok := l.modifier(name); ok {
 		var args string
+		var parsedArgs bool
 		if hasArgs {
-			var parsedArgs bool
 			switch pathOut[0] {
 			case '{', '[', '"':
 				// json arg
@@ -2908,9 +4123,57 @@ func execModifier(json, path string) (pathOut, res string, ok bool) {
 				pathOut = pathOut[i:]
 			}
 		}
-		return pathOut, fn(json, args), true
+		if !l.call(json) {
+			return pathOut, "", nil, true
+		}
+		var arg Result
+		if parsedArgs {
+			arg = Parse(args)
+			arg.Raw = args
+		} else if hasArgs {
+			arg = Result{Type: String, Str: args, Raw: args}
+		}
+		root := json
+		if l != nil && l.rooted {
+			root = l.root
+		}
+		var mres Result
+		var err error
+		if sameModifier(modDig, fn) {
+			// the built-in @dig searches with the limits of the search
+			mres = modifierResult(modDigLimit(json, arg.Raw, l))
+			if l != nil && l.err != nil {
+				return pathOut, "", nil, true
+			}
+		} else {
+			mres, err = fn.Modify(l.context(), modifierResult(json),
+				modifierResult(root), arg)
+		}
+		if err != nil {
+			if l != nil {
+				l.fail(err)
+			}
+			return pathOut, "", nil, true
+		}
+		res = mres.Raw
+		if res == "" && mres.Exists() {
+			res = mres.rawJSON()
+		}
+		l.emit(len(res))
+		if res == mres.Raw {
+			indexes = modifierIndexes(fn, json, arg.Raw)
+		}
+		return pathOut, res, indexes, true
 	}
-	return pathOut, res, false
+	return pathOut, res, nil, false
+}
+
+// modifierResult returns the json as a result for a modifier, keeping the
+// json as the Raw.
+func modifierResult(json string) Result {
+	res := Parse(json)
+	res.Raw = json
+	return res
 }
 
 // unwrap removes the '[]' or '{}' characters around json
@@ -2925,37 +4188,30 @@ func unwrap(json string) string {
 // DisableModifiers will disable the modifier syntax
 var DisableModifiers = false
 
-var modifiers map[string]func(json, arg string) string
+// builtinModifiers are the modifiers that come with this package.
+var builtinModifiers map[string]func(json, arg string) string
 
 func init() {
-	modifiers = map[string]func(json, arg string) string{
-		"pretty":  modPretty,
-		"ugly":    modUgly,
-		"reverse": modReverse,
-		"this":    modThis,
-		"flatten": modFlatten,
-		"join":    modJoin,
-		"valid":   modValid,
-		"keys":    modKeys,
-		"values":  modValues,
-		"tostr":   modToStr,
-		"fromstr": modFromStr,
-		"group":   modGroup,
-		"dig":     modDig,
-	}
-}
-
-// AddModifier binds a custom modifier command to the GJSON syntax.
-// This operation is not thread safe and should be executed prior to
-// using all other gjson function.
-func AddModifier(name string, fn func(json, arg string) string) {
-	modifiers[name] = fn
-}
-
-// ModifierExists returns true when the specified modifier exists.
-func ModifierExists(name string, fn func(json, arg string) string) bool {
-	_, ok := modifiers[name]
-	return ok
+	builtinModifiers = map[string]func(json, arg string) string{
+		"pretty":        modPretty,
+		"ugly":          modUgly,
+		"reverse":       modReverse,
+		"this":          modThis,
+		"flatten":       modFlatten,
+		"join":          modJoin,
+		"valid":         modValid,
+		"keys":          modKeys,
+		"values":        modValues,
+		"tostr":         modToStr,
+		"fromstr":       modFromStr,
+		"group":         modGroup,
+		"dig":           modDig,
+		"date":          modDate,
+		"canonical":     modCanonical,
+		"stripcomments": modStripComments,
+		"dupes":         modDupes,
+	}
+	initModifiers()
 }
 
 // cleanWS remove any non-whitespace from string
@@ -3292,6 +4548,149 @@ func modGroup(json, arg string) string {
 	return string(data)
 }
 
+// @date parses a timestamp and reformats it.
+//
+//	"2024-03-10T15:04:05-07:00" -> "2024-03-10T22:04:05Z"  // @date:{"tz":"UTC"}
+//	1710108245                  -> "2024-03-10"            // @date:DateOnly
+//
+// The input may be an RFC3339 string or a Unix epoch number, as detected by
+// Result.TimeE. The arg may be an output layout, or an object with the
+// following options:
+//
+//	layout    input layout, such as "RFC1123" or "2006-01-02 15:04"
+//	format    output layout, or one of "unix", "unixmilli", "unixmicro" and
+//	          "unixnano" for an epoch number. Default is RFC3339Nano.
+//	tz        time zone to shift into, such as "UTC" or "America/Phoenix"
+//	truncate  "second", "minute", "hour", "day", "month", "year", or a
+//	          duration such as "15m"
+//	add       duration to add, such as "-36h"
+//	before, after, equal
+//	          a timestamp to compare with, which makes the result a boolean
+//
+// An empty string is returned when the json is not a valid timestamp.
+func modDate(json, arg string) string {
+	var layout, format, tz, trunc, add string
+	var cmps []Result
+	if arg != "" {
+		args := Parse(arg)
+		if args.IsObject() {
+			args.ForEach(func(key, value Result) bool {
+				switch key.String() {
+				case "layout":
+					layout = value.String()
+				case "format":
+					format = value.String()
+				case "tz":
+					tz = value.String()
+				case "truncate":
+					trunc = value.String()
+				case "add":
+					add = value.String()
+				case "before", "after", "equal":
+					cmps = append(cmps, key, value)
+				}
+				return true
+			})
+		} else if args.Type == String {
+			format = args.Str
+		} else {
+			format = trim(arg)
+		}
+	}
+	var layouts []string
+	if layout != "" {
+		layouts = append(layouts, layout)
+	}
+	tm, err := Parse(json).TimeE(layouts...)
+	if err != nil {
+		return ""
+	}
+	if add != "" {
+		d, err := time.ParseDuration(add)
+		if err != nil {
+			return ""
+		}
+		tm = tm.Add(d)
+	}
+	if tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return ""
+		}
+		tm = tm.In(loc)
+	}
+	if trunc != "" {
+		var ok bool
+		if tm, ok = truncateTime(tm, trunc); !ok {
+			return ""
+		}
+	}
+	if len(cmps) > 0 {
+		for i := 0; i < len(cmps); i += 2 {
+			other, err := cmps[i+1].TimeE(layouts...)
+			if err != nil {
+				return ""
+			}
+			var ok bool
+			switch cmps[i].String() {
+			case "before":
+				ok = tm.Before(other)
+			case "after":
+				ok = tm.After(other)
+			case "equal":
+				ok = tm.Equal(other)
+			}
+			if !ok {
+				return "false"
+			}
+		}
+		return "true"
+	}
+	switch format {
+	case "unix":
+		return strconv.FormatInt(tm.Unix(), 10)
+	case "unixmilli":
+		return strconv.FormatInt(tm.UnixNano()/1e6, 10)
+	case "unixmicro":
+		return strconv.FormatInt(tm.UnixNano()/1e3, 10)
+	case "unixnano":
+		return strconv.FormatInt(tm.UnixNano(), 10)
+	case "":
+		format = time.RFC3339Nano
+	default:
+		if named, ok := timeLayouts[format]; ok {
+			format = named
+		}
+	}
+	return string(AppendJSONString(nil, tm.Format(format)))
+}
+
+// truncateTime rounds tm down to a calendar unit or a multiple of a duration.
+// Calendar units are truncated in the location of tm.
+func truncateTime(tm time.Time, unit string) (time.Time, bool) {
+	y, m, d := tm.Date()
+	switch unit {
+	case "second":
+		return tm.Truncate(time.Second), true
+	case "minute":
+		return time.Date(y, m, d, tm.Hour(), tm.Minute(), 0, 0,
+			tm.Location()), true
+	case "hour":
+		return time.Date(y, m, d, tm.Hour(), 0, 0, 0, tm.Location()), true
+	case "day":
+		return time.Date(y, m, d, 0, 0, 0, 0, tm.Location()), true
+	case "month":
+		return time.Date(y, m, 1, 0, 0, 0, 0, tm.Location()), true
+	case "year":
+		return time.Date(y, 1, 1, 0, 0, 0, 0, tm.Location()), true
+	}
+	dur, err := time.ParseDuration(unit)
+	if err != nil || dur <= 0 {
+		return tm, false
+	}
+	return tm.Truncate(dur), true
+}
+
 // stringHeader instead of reflect.StringHeader
 type stringHeader struct {
 	data unsafe.Pointer
@@ -3308,11 +4707,11 @@ type sliceHeader struct {
 // getBytes casts the input json bytes to a string and safely returns the
 // results as uniquely allocated data. This operation is intended to minimize
 // copies and allocations for the large json string->[]byte.
-func getBytes(json []byte, path string) Result {
+func getBytes(json []byte, path string, l *limiter) Result {
 	var result Result
 	if json != nil {
 		// unsafe cast to string
-		result = Get(*(*string)(unsafe.Pointer(&json)), path)
+		result = getLimit(*(*string)(unsafe.Pointer(&json)), path, l)
 		// safely get the string headers
 		rawhi := *(*stringHeader)(unsafe.Pointer(&result.Raw))
 		strhi := *(*stringHeader)(unsafe.Pointer(&result.Str))
@@ -3352,6 +4751,43 @@ func getBytes(json []byte, path string) Result {
 	return result
 }
 
+// rawAt reports whether raw is the json at index i.
+func rawAt(json string, i int, raw string) bool {
+	return raw != "" && i >= 0 && i+len(raw) <= len(json) &&
+		json[i:i+len(raw)] == raw
+}
+
+// rawIndex returns the index of a result in json, or zero when the result
+// isn't there because it was computed.
+func rawIndex(json string, res Result) int {
+	if rawAt(json, res.Index, res.Raw) {
+		return res.Index
+	}
+	return 0
+}
+
+// sliceIndex returns the index of sub in json when sub is a slice of the
+// memory of json, or -1 when it's not.
+func sliceIndex(json, sub string) int {
+	if len(sub) == 0 {
+		return -1
+	}
+	jhdr := *(*stringHeader)(unsafe.Pointer(&json))
+	shdr := *(*stringHeader)(unsafe.Pointer(&sub))
+	i := int(uintptr(shdr.data) - uintptr(jhdr.data))
+	if uintptr(shdr.data) < uintptr(jhdr.data) || i+len(sub) > len(json) {
+		return -1
+	}
+	return i
+}
+
+// sliceAt reports whether raw is the slice of the memory of json at index i.
+func sliceAt(json string, i int, raw string) bool {
+	return uint(i) < uint(len(json)) && len(raw) > 0 &&
+		(*stringHeader)(unsafe.Pointer(&raw)).data ==
+			unsafe.Add((*stringHeader)(unsafe.Pointer(&json)).data, i)
+}
+
 // fillIndex finds the position of Raw data and assigns it to the Index field
 // of the resulting value. If the position cannot be found then Index zero is
 // used instead.
@@ -3427,7 +4863,7 @@ func revSquash(json string) string {
 }
 
 // Paths returns the original GJSON paths for a Result where the Result came
-// from a simple query path that returns an array, like:
+// from a query path or a multipath that returns an array, like:
 //
 //	gjson.Get(json, "friends.#.first")
 //
@@ -3437,9 +4873,8 @@ func revSquash(json string) string {
 //
 // The param 'json' must be the original JSON used when calling Get.
 //
-// Returns an empty string if the paths cannot be determined, which can happen
-// when the Result came from a path that contained a multipath, modifier,
-// or a nested query.
+// Returns nil if the Result has no Indexes. The path of an element that has
+// no origin, such as the output of @pretty, is an empty string.
 func (t Result) Paths(json string) []string {
 	if t.Indexes == nil {
 		return nil
@@ -3456,7 +4891,7 @@ func (t Result) Paths(json string) []string {
 }
 
 // Path returns the original GJSON path for a Result where the Result came
-// from a simple path that returns a single value, like:
+// from a path that returns a single value, like:
 //
 //	gjson.Get(json, "friends.#(last=Murphy)")
 //
@@ -3466,9 +4901,8 @@ func (t Result) Paths(json string) []string {
 //
 // The param 'json' must be the original JSON used when calling Get.
 //
-// Returns an empty string if the paths cannot be determined, which can happen
-// when the Result came from a path that contained a multipath, modifier,
-// or a nested query.
+// Returns an empty string if the Result has no origin, such as when it's
+// the output of a modifier or a multipath. See HasOrigin.
 func (t Result) Path(json string) string {
 	var path []byte
 	var comps []string // raw components
@@ -3591,25 +5025,37 @@ func Escape(comp string) string {
 	return comp
 }
 
-func parseRecursiveDescent(all []Result, parent Result, path string) []Result {
-	if res := parent.Get(path); res.Exists() {
-		all = append(all, res)
-	}
-	if parent.IsArray() || parent.IsObject() {
-		parent.ForEach(func(_, val Result) bool {
-			all = parseRecursiveDescent(all, val, path)
-			return true
-		})
-	}
-	return all
+// modDig applies the path to each value of json, in the order that the
+// values start, and returns an array of the results that exist.
+func modDig(json, arg string) string {
+	return modDigLimit(json, arg, nil)
 }
 
-func modDig(json, arg string) string {
-	all := parseRecursiveDescent(nil, Parse(json), arg)
+// modDigLimit is modDig within the limits of a search. Each value counts as
+// a step, and applying the path to it counts as a step for every 16 bytes
+// of the value, like a modifier call. It returns an empty string when a
+// limit is exceeded or the context is done.
+func modDigLimit(json, arg string, l *limiter) string {
+	d := &descender{json: json, lim: l, all: true}
+	found := d.walk()
+	if l != nil && l.err != nil {
+		return ""
+	}
 	var out []byte
 	out = append(out, '[')
-	for i, res := range all {
-		if i > 0 {
+	for _, m := range found {
+		value := json[m.start:m.end]
+		if !l.step(2 + len(value)/modifierStepBytes) {
+			return ""
+		}
+		res := getLimit(value, arg, l)
+		if l != nil && l.err != nil {
+			return ""
+		}
+		if !res.Exists() {
+			continue
+		}
+		if len(out) > 1 {
 			out = append(out, ',')
 		}
 		out = append(out, res.Raw...)
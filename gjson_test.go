@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
@@ -1026,7 +1027,7 @@ var complicatedJSON = `
 
 func testvalid(t *testing.T, json string, expect bool) {
 	t.Helper()
-	_, ok := validpayload([]byte(json), 0)
+	_, ok := validpayload([]byte(json), 0, false)
 	if ok != expect {
 		t.Fatal("mismatch")
 	}
@@ -1124,14 +1125,14 @@ func TestValidRandom(t *testing.T) {
 	for time.Since(start) < time.Second*3 {
 		n := rand.Int() % len(b)
 		rand.Read(b[:n])
-		validpayload(b[:n], 0)
+		validpayload(b[:n], 0, false)
 	}
 
 	start = time.Now()
 	for time.Since(start) < time.Second*3 {
 		n := rand.Int() % len(b)
 		makeRandomJSONChars(b[:n])
-		validpayload(b[:n], 0)
+		validpayload(b[:n], 0, false)
 	}
 }
 
@@ -2269,7 +2270,7 @@ func TestIndexes(t *testing.T) {
 		},
 		{
 			`objectArray.@reverse.#.first`,
-			nil,
+			[]string{`"`, `"`},
 		},
 	}
 
@@ -2736,3 +2737,341 @@ func TestEscape(t *testing.T) {
 	assert(t, user.Get(Escape("last.name")).String() == "Prichard")
 	assert(t, user.Get("first.name").String() == "")
 }
+
+func TestTimeE(t *testing.T) {
+	want := time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)
+	for _, json := range []string{
+		`1700000000`, `1700000000000`, `1700000000000000`,
+		`1700000000000000000`, `"2023-11-14T22:13:20Z"`,
+	} {
+		tm, err := Parse(json).TimeE()
+		assert(t, err == nil && tm.Equal(want))
+	}
+	tm, err := Parse(`1700000000.5`).TimeE()
+	assert(t, err == nil && tm.Equal(want.Add(time.Second/2)))
+	// the unit of a fraction is detected from the integer part
+	tm, err = Parse(`1700000000000.5`).TimeE()
+	assert(t, err == nil && tm.Equal(want.Add(time.Millisecond/2)))
+	tm, err = Parse(`17000000000e-1`).TimeE()
+	assert(t, err == nil && tm.Equal(want))
+	tm, err = Parse(`1.7e18`).TimeE()
+	assert(t, err == nil && tm.Equal(want))
+	tm, err = Parse(`-1.25`).TimeE()
+	assert(t, err == nil && tm.Equal(time.Unix(-2, 75e7)))
+	tm, err = Parse(`0e-999999999`).TimeE()
+	assert(t, err == nil && tm.Equal(time.Unix(0, 0)))
+	for _, raw := range []string{`9223372036854775808`, `1e19`,
+		`-99999999999999999999.5`, `1e999999999`} {
+		_, err = Parse(raw).TimeE()
+		assert(t, errors.Is(err, ErrRange))
+	}
+	tm, err = Parse(`"14 Nov 23 22:13 UTC"`).TimeE("RFC3339", "RFC822")
+	assert(t, err == nil && tm.Equal(want.Add(-20*time.Second)))
+	tm, err = Parse(`"20231114"`).TimeE("20060102")
+	assert(t, err == nil && tm.Equal(time.Date(2023, 11, 14, 0, 0, 0, 0,
+		time.UTC)))
+	_, err = Parse(`"yesterday"`).TimeE()
+	assert(t, err != nil)
+	_, err = Parse(`true`).TimeE()
+	assert(t, err != nil)
+	_, err = Get(`{}`, "missing").TimeE()
+	assert(t, err != nil)
+	assert(t, Parse(`1700000000`).Time().Equal(want))
+	assert(t, Parse(`"2023-11-14"`).TimeLayout("DateOnly").Equal(
+		time.Date(2023, 11, 14, 0, 0, 0, 0, time.UTC)))
+	assert(t, Parse(`"2023-11-14"`).Time().IsZero())
+	// strings are never epoch timestamps
+	assert(t, Parse(`"2024"`).Time().IsZero())
+	_, err = Parse(`"1700000000"`).TimeE()
+	assert(t, err != nil)
+}
+
+func TestModDate(t *testing.T) {
+	json := `{"created":"2024-03-10T15:04:05.25-07:00","epoch":1710108245}`
+	assert(t, Get(json, `created.@date`).String() ==
+		"2024-03-10T15:04:05.25-07:00")
+	assert(t, Get(json, `created.@date:{"tz":"UTC"}`).String() ==
+		"2024-03-10T22:04:05.25Z")
+	assert(t, Get(json, `created.@date:DateOnly`).String() == "2024-03-10")
+	assert(t, Get(json, `created.@date:2006/01/02`).String() == "2024/03/10")
+	assert(t, Get(json, `created.@date:unix`).Int() == 1710108245)
+	assert(t, Get(json, `epoch.@date`).String() == "2024-03-10T22:04:05Z")
+	assert(t, Get(json, `epoch.@date:{"format":"unixmilli"}`).Int() ==
+		1710108245000)
+	assert(t, Get(json, `created.@date:{"truncate":"day"}`).String() ==
+		"2024-03-10T00:00:00-07:00")
+	assert(t, Get(json, `created.@date:{"tz":"UTC","truncate":"month"}`).
+		String() == "2024-03-01T00:00:00Z")
+	assert(t, Get(json, `created.@date:{"truncate":"15m","tz":"UTC"}`).
+		String() == "2024-03-10T22:00:00Z")
+	assert(t, Get(json, `created.@date:{"add":"-24h","format":"DateOnly"}`).
+		String() == "2024-03-09")
+	assert(t, Get(json, `created.@date:{"after":"2024-03-10T21:00:00Z"}`).
+		Bool())
+	assert(t, !Get(json, `created.@date:{"before":1710108245}`).Bool())
+	assert(t, Get(json, `created.@date:{"equal":1710108245250}`).Bool())
+	assert(t, !Get(json, `missing.@date`).Exists())
+	assert(t, !Get(`"never"`, `@date`).Exists())
+	assert(t, !Get(json, `created.@date:{"tz":"Nowhere/Special"}`).Exists())
+}
+
+func TestQueryTime(t *testing.T) {
+	json := `[
+		{"id":1,"created":"2023-12-31T23:30:00-02:00"},
+		{"id":2,"created":"2024-01-01T00:30:00+02:00"},
+		{"id":3,"created":"2024-01-02"}
+	]`
+	assert(t, Get(json, `#(created>"2024-01-01")#.id`).Raw == `[1,3]`)
+	assert(t, Get(json, `#(created<="2024-01-01T00:00:00Z")#.id`).Raw ==
+		`[2]`)
+	assert(t, Get(json, `#(created=="2024-01-02")#.id`).Raw == `[3]`)
+	json = `[{"v":"abc"},{"v":"2024-01-02"}]`
+	assert(t, Get(json, `#(v>"2024")#.v`).Raw == `["abc","2024-01-02"]`)
+}
+
+func TestBigNumbers(t *testing.T) {
+	json := `{"id":12345678901234567891,"neg":-98765432109876543210,
+		"exp":1.5e3,"frac":1.50,"small":12e-4,"str":"9007199254740993",
+		"word":"abc","t":true}`
+	n, ok := Get(json, "id").BigInt()
+	assert(t, ok && n.String() == "12345678901234567891")
+	n, ok = Get(json, "neg").BigInt()
+	assert(t, ok && n.String() == "-98765432109876543210")
+	n, ok = Get(json, "exp").BigInt()
+	assert(t, ok && n.String() == "1500")
+	n, ok = Get(json, "str").BigInt()
+	assert(t, ok && n.String() == "9007199254740993")
+	_, ok = Get(json, "frac").BigInt()
+	assert(t, !ok)
+	_, ok = Get(json, "word").BigInt()
+	assert(t, !ok)
+	_, ok = Get(json, "t").BigInt()
+	assert(t, !ok)
+	_, ok = Parse(`1e999999999`).BigInt()
+	assert(t, !ok)
+	n, ok = Parse(`0.000e5`).BigInt()
+	assert(t, ok && n.Sign() == 0)
+
+	f, ok := Get(json, "id").BigFloat()
+	assert(t, ok && f.Text('f', 0) == "12345678901234567891")
+	f, ok = Get(json, "small").BigFloat()
+	assert(t, ok && f.Text('g', 10) == "0.0012")
+	_, ok = Get(json, "word").BigFloat()
+	assert(t, !ok)
+
+	assert(t, Get(json, "frac").Decimal() == "1.50")
+	assert(t, Get(json, "exp").Decimal() == "1500")
+	assert(t, Get(json, "small").Decimal() == "0.0012")
+	assert(t, Get(json, "neg").Decimal() == "-98765432109876543210")
+	assert(t, Parse(`-2E+3`).Decimal() == "-2000")
+	assert(t, Parse(`123.456e-1`).Decimal() == "12.3456")
+	assert(t, Parse(`0.00`).Decimal() == "0.00")
+	assert(t, Parse(`-0.0e-1`).Decimal() == "0.00")
+	assert(t, Parse(`0e5`).Decimal() == "0")
+	assert(t, Parse(`1e99999`).Decimal() == "1e99999")
+	assert(t, Get(json, "word").Decimal() == "")
+	assert(t, Get(json, "missing").Decimal() == "")
+
+	assert(t, Get(json, "id").Number() == "12345678901234567891")
+	assert(t, Get(json, "str").Number() == "9007199254740993")
+	assert(t, Get(json, "word").Number() == "")
+	assert(t, Get(`[1,2,3]`, "#").Number() == "3")
+}
+
+func TestNumberGrammar(t *testing.T) {
+	for _, raw := range []string{`"+5"`, `".5"`, `"5."`, `"05"`, `"-"`,
+		`"1e"`, `"NaN"`} {
+		res := Parse(raw)
+		assert(t, res.Number() == "" && res.Decimal() == "")
+		_, ok := res.BigInt()
+		assert(t, !ok)
+		_, ok = res.BigFloat()
+		assert(t, !ok)
+	}
+	for _, raw := range []string{`"-0.5"`, `"1E+2"`, `"0"`, `-1.5e-3`} {
+		n := Parse(raw).Number()
+		b, err := json.Marshal(n)
+		assert(t, n != "" && err == nil && string(b) == string(n))
+	}
+}
+
+func TestQueryBigIntegers(t *testing.T) {
+	json := `[
+		{"id":9007199254740993,"name":"a"},
+		{"id":9007199254740992,"name":"b"},
+		{"id":-9007199254740993,"name":"c"},
+		{"id":1.5,"name":"d"}
+	]`
+	assert(t, Get(json, `#(id==9007199254740993).name`).String() == "a")
+	assert(t, Get(json, `#(id==9007199254740992).name`).String() == "b")
+	assert(t, Get(json, `#(id>9007199254740992)#.name`).Raw == `["a"]`)
+	assert(t, Get(json, `#(id<-9007199254740992)#.name`).Raw == `["c"]`)
+	assert(t, Get(json, `#(id!=9007199254740993)#.name`).Raw ==
+		`["b","c","d"]`)
+	assert(t, Get(json, `#(id>1)#.name`).Raw == `["a","b","d"]`)
+	assert(t, Get(json, `#(id==1.5).name`).String() == "d")
+}
+
+func TestStrictAccessors(t *testing.T) {
+	json := `{"int":42,"neg":-1,"frac":9.7,"whole":2.0,"exp":1e3,
+		"max":9223372036854775807,"min":-9223372036854775808,
+		"over":9223372036854775808,"umax":18446744073709551615,
+		"uover":18446744073709551616,"sint":"42","sfrac":"9.7",
+		"word":"abc","yes":true,"no":false,"sbool":"TRUE","nil":null,
+		"obj":{},"huge":1e400}`
+	isErr := func(err, target error) bool {
+		var cerr *ConvError
+		return errors.As(err, &cerr) && errors.Is(err, target)
+	}
+	n, err := Get(json, "int").IntE()
+	assert(t, err == nil && n == 42)
+	n, err = Get(json, "whole").IntE()
+	assert(t, err == nil && n == 2)
+	n, err = Get(json, "exp").IntE()
+	assert(t, err == nil && n == 1000)
+	n, err = Get(json, "sint").IntE()
+	assert(t, err == nil && n == 42)
+	n, err = Get(json, "max").IntE()
+	assert(t, err == nil && n == math.MaxInt64)
+	n, err = Get(json, "min").IntE()
+	assert(t, err == nil && n == math.MinInt64)
+	_, err = Get(json, "over").IntE()
+	assert(t, isErr(err, ErrRange))
+	_, err = Parse(`1e999999999`).IntE()
+	assert(t, isErr(err, ErrRange))
+	_, err = Parse(`1e9999999999`).UintE()
+	assert(t, isErr(err, ErrRange))
+	_, err = Parse(`1e-9999999999`).IntE()
+	assert(t, isErr(err, ErrFraction))
+	_, err = Get(json, "frac").IntE()
+	assert(t, isErr(err, ErrFraction))
+	_, err = Get(json, "sfrac").IntE()
+	assert(t, isErr(err, ErrFraction))
+	_, err = Get(json, "word").IntE()
+	assert(t, isErr(err, ErrSyntax))
+	_, err = Get(json, "yes").IntE()
+	assert(t, isErr(err, ErrType))
+	_, err = Get(json, "missing").IntE()
+	assert(t, isErr(err, ErrNotExist))
+	assert(t, err.Error() == "gjson.IntE: value does not exist")
+	_, err = Get(json, "frac").IntE()
+	assert(t, err.Error() ==
+		`gjson.IntE: converting Number "9.7": value has a fractional part`)
+
+	u, err := Get(json, "umax").UintE()
+	assert(t, err == nil && u == math.MaxUint64)
+	_, err = Get(json, "uover").UintE()
+	assert(t, isErr(err, ErrRange))
+	_, err = Get(json, "neg").UintE()
+	assert(t, isErr(err, ErrRange))
+	_, err = Get(json, "frac").UintE()
+	assert(t, isErr(err, ErrFraction))
+	_, err = Get(json, "nil").UintE()
+	assert(t, isErr(err, ErrType))
+
+	f, err := Get(json, "frac").FloatE()
+	assert(t, err == nil && f == 9.7)
+	f, err = Get(json, "sfrac").FloatE()
+	assert(t, err == nil && f == 9.7)
+	f, err = Get(`[1,2]`, "#").FloatE()
+	assert(t, err == nil && f == 2)
+	_, err = Get(json, "huge").FloatE()
+	assert(t, isErr(err, ErrRange))
+	_, err = Get(json, "word").FloatE()
+	assert(t, isErr(err, ErrSyntax))
+	for _, raw := range []string{`"NaN"`, `"Infinity"`, `"-inf"`, `"0x1p3"`,
+		`NaN`} {
+		_, err = Parse(raw).FloatE()
+		assert(t, isErr(err, ErrSyntax))
+	}
+	_, err = Get(json, "obj").FloatE()
+	assert(t, isErr(err, ErrType))
+
+	b, err := Get(json, "yes").BoolE()
+	assert(t, err == nil && b)
+	b, err = Get(json, "no").BoolE()
+	assert(t, err == nil && !b)
+	b, err = Get(json, "sbool").BoolE()
+	assert(t, err == nil && b)
+	_, err = Get(json, "word").BoolE()
+	assert(t, isErr(err, ErrSyntax))
+	_, err = Get(json, "int").BoolE()
+	assert(t, isErr(err, ErrType))
+	_, err = Get(json, "missing").BoolE()
+	assert(t, isErr(err, ErrNotExist))
+
+	s, err := Get(json, "word").StringE()
+	assert(t, err == nil && s == "abc")
+	_, err = Get(json, "int").StringE()
+	assert(t, isErr(err, ErrType))
+	_, err = Get(json, "missing").StringE()
+	assert(t, isErr(err, ErrNotExist))
+
+	_, err = Get(json, "word").TimeE()
+	var perr *time.ParseError
+	assert(t, errors.As(err, &perr))
+	_, err = Get(json, "missing").TimeE()
+	assert(t, isErr(err, ErrNotExist))
+}
+
+func TestEqualCompare(t *testing.T) {
+	eq := func(a, b string) bool { return Parse(a).Equal(Parse(b)) }
+	assert(t, eq(`{"a":1.0,"b":"A"}`, ` { "b" : "A", "a" : 1 } `))
+	assert(t, eq(`[1,{"x":[true,null]}]`, `[1e0, {"x": [true, null]}]`))
+	assert(t, eq(`100`, `1e2`))
+	assert(t, eq(`-0.50`, `-5E-1`))
+	assert(t, eq(`0`, `-0.0`))
+	assert(t, eq(`12345678901234567891`, `12345678901234567891.0`))
+	assert(t, !eq(`12345678901234567891`, `12345678901234567892`))
+	assert(t, !eq(`{"a":1}`, `{"a":1,"b":2}`))
+	assert(t, !eq(`[1,2]`, `[2,1]`))
+	assert(t, !eq(`"1"`, `1`))
+	assert(t, !eq(`null`, ``))
+	assert(t, eq(`{"a":1,"a":2}`, `{"a":1}`))
+	assert(t, Get(`[1,2]`, "#").Equal(Parse(`2.0`)))
+
+	cmp := func(a, b string) int { return Parse(a).Compare(Parse(b)) }
+	assert(t, cmp(``, `null`) < 0)
+	assert(t, cmp(`null`, `false`) < 0)
+	assert(t, cmp(`false`, `-1`) < 0)
+	assert(t, cmp(`1e10`, `"a"`) < 0)
+	assert(t, cmp(`"z"`, `true`) < 0)
+	assert(t, cmp(`true`, `[]`) < 0)
+	assert(t, cmp(`[9]`, `{}`) < 0)
+	assert(t, cmp(`-2`, `-1.5`) < 0)
+	assert(t, cmp(`0.001`, `0.01`) < 0)
+	assert(t, cmp(`99`, `100`) < 0)
+	assert(t, cmp(`0`, `0.0001`) < 0)
+	assert(t, cmp(`-0.0001`, `0`) < 0)
+	assert(t, cmp(`"a"`, `"b"`) < 0)
+	assert(t, cmp(`[1,2]`, `[1,2,0]`) < 0)
+	assert(t, cmp(`[1,3]`, `[1,2,0]`) > 0)
+	assert(t, cmp(`{"a":1}`, `{"b":0}`) < 0)
+	assert(t, cmp(`{"a":1}`, `{"a":2}`) < 0)
+	assert(t, cmp(`{"b":1,"a":1}`, `{"a":1,"b":1}`) == 0)
+	// consistent with Less across types
+	vals := []string{`null`, `false`, `1`, `"a"`, `true`, `[1]`}
+	for i := 0; i < len(vals); i++ {
+		for j := 0; j < len(vals); j++ {
+			a, b := Parse(vals[i]), Parse(vals[j])
+			if a.Type != b.Type {
+				assert(t, (a.Compare(b) < 0) == a.Less(b, true))
+			}
+		}
+	}
+}
+
+func TestQueryStructuralEquality(t *testing.T) {
+	json := `{"items":[
+		{"id":1,"tags":["a","b"],"dims":{"w":1,"h":2}},
+		{"id":2,"tags":["b","a"],"dims":{"h":2.0,"w":1}},
+		{"id":3,"tags":[],"dims":{"w":3}}
+	]}`
+	assert(t, Get(json, `items.#(tags==["a","b"]).id`).Int() == 1)
+	assert(t, Get(json, `items.#(tags==[ "b", "a" ])#.id`).Raw == `[2]`)
+	assert(t, Get(json, `items.#(dims=={"w":1,"h":2})#.id`).Raw == `[1,2]`)
+	assert(t, Get(json, `items.#(dims!={"w":1,"h":2})#.id`).Raw == `[3]`)
+	assert(t, Get(json, `items.#(tags==[])#.id`).Raw == `[3]`)
+	assert(t, Get(json, `items.#.tags|#(==["a","b"])`).Raw == `["a","b"]`)
+	assert(t, Get(json, `items.#.tags|#(!=[])#`).Raw == `[["a","b"],["b","a"]]`)
+}
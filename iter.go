@@ -0,0 +1,58 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.23
+
+package gjson
+
+import "iter"
+
+// All returns an iterator over the keys and values of an object or array,
+// with the same behavior as ForEach.
+//
+//	for key, value := range gjson.Get(json, "friends.0").All() {
+//		println(key.String(), value.String())
+//	}
+func (t Result) All() iter.Seq2[Result, Result] {
+	return func(yield func(key, value Result) bool) {
+		t.ForEach(yield)
+	}
+}
+
+// Values returns an iterator over the values of an object or array, with the
+// same behavior as ForEach.
+//
+//	for value := range gjson.Get(json, "children").Values() {
+//		println(value.String())
+//	}
+func (t Result) Values() iter.Seq[Result] {
+	return func(yield func(value Result) bool) {
+		t.ForEach(func(_, value Result) bool {
+			return yield(value)
+		})
+	}
+}
+
+// Keys returns an iterator over the keys of an object, with the same
+// behavior as ForEach. For an array the keys are the Number indexes of the
+// elements.
+func (t Result) Keys() iter.Seq[Result] {
+	return func(yield func(key Result) bool) {
+		t.ForEach(func(key, _ Result) bool {
+			return yield(key)
+		})
+	}
+}
+
+// Lines returns an iterator over lines of JSON as specified by the JSON Lines
+// format (http://jsonlines.org/), with the same behavior as ForEachLine.
+//
+//	for line := range gjson.Lines(json) {
+//		println(line.Get("name").String())
+//	}
+func Lines(json string) iter.Seq[Result] {
+	return func(yield func(line Result) bool) {
+		ForEachLine(json, yield)
+	}
+}
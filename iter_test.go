@@ -0,0 +1,78 @@
+//go:build go1.23
+
+package gjson
+
+import "testing"
+
+func TestIterAll(t *testing.T) {
+	json := `{"a":1,"b":[true,false],"c":"three"}`
+	var keys, values []string
+	for key, value := range Parse(json).All() {
+		keys = append(keys, key.String())
+		values = append(values, value.Raw)
+	}
+	assert(t, len(keys) == 3 && keys[0] == "a" && keys[2] == "c")
+	assert(t, values[1] == `[true,false]`)
+	var n int
+	for key, value := range Get(json, "b").All() {
+		assert(t, key.Int() == int64(n) && value.IsBool())
+		n++
+	}
+	assert(t, n == 2)
+	for key := range Parse(json).All() {
+		if key.String() == "b" {
+			break
+		}
+		assert(t, key.String() == "a")
+	}
+}
+
+func TestIterValuesKeys(t *testing.T) {
+	json := `{"children":["Sara","Alex","Jack"],"name":{"first":"Tom"}}`
+	var names []string
+	for value := range Get(json, "children").Values() {
+		names = append(names, value.String())
+	}
+	assert(t, len(names) == 3 && names[1] == "Alex")
+	var keys []string
+	for key := range Parse(json).Keys() {
+		keys = append(keys, key.String())
+	}
+	assert(t, len(keys) == 2 && keys[0] == "children" && keys[1] == "name")
+	for range Get(json, "missing").Values() {
+		t.Fatal("expected no values")
+	}
+	var n int
+	for value := range Get(json, "name.first").Values() {
+		assert(t, value.String() == "Tom")
+		n++
+	}
+	assert(t, n == 1)
+}
+
+func TestIterLines(t *testing.T) {
+	json := `
+		{"name": "Gilbert", "age": 61}
+		{"name": "Alexa", "age": 34}
+		{"name": "May", "age": 57}
+	`
+	var names []string
+	for line := range Lines(json) {
+		names = append(names, line.Get("name").String())
+		if len(names) == 2 {
+			break
+		}
+	}
+	assert(t, len(names) == 2 && names[1] == "Alexa")
+}
+
+func TestIterAllocs(t *testing.T) {
+	res := Parse(`[1,2,3,4,5,6,7,8,9,10]`)
+	var sum int64
+	allocs := testing.AllocsPerRun(100, func() {
+		for value := range res.Values() {
+			sum += value.Int()
+		}
+	})
+	assert(t, allocs <= 1)
+}
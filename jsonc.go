@@ -0,0 +1,148 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gjson
+
+import "strings"
+
+// StripComments converts JSONC, which is json with comments and trailing
+// commas, to json. The "//" and "/* */" comments and the trailing commas
+// are replaced with spaces, and newlines are kept, so that the byte offsets
+// and line numbers of all values are the same as in the original.
+//
+//	{"a": 1, /* b */ "c": [1,2,], // d
+//	}
+//	->
+//	{"a": 1,         "c": [1,2 ],
+//	}
+//
+// The json is returned as is when there's nothing to strip.
+func StripComments(json string) string {
+	var out []byte
+	blank := func(i int) {
+		if out == nil {
+			out = []byte(json)
+		}
+		if out[i] != '\n' && out[i] != '\r' {
+			out[i] = ' '
+		}
+	}
+	// the comma after the last value, which is trailing when it's followed
+	// by a '}' or ']'
+	comma := -1
+	value := false
+	for i := 0; i < len(json); i++ {
+		switch json[i] {
+		case ' ', '\t', '\n', '\r':
+		case '"':
+			for i++; i < len(json); i++ {
+				if json[i] == '\\' {
+					i++
+				} else if json[i] == '"' {
+					break
+				}
+			}
+			comma, value = -1, true
+		case '/':
+			if i+1 < len(json) && json[i+1] == '/' {
+				for ; i < len(json) && json[i] != '\n'; i++ {
+					blank(i)
+				}
+			} else if i+1 < len(json) && json[i+1] == '*' {
+				blank(i)
+				blank(i + 1)
+				for i += 2; i < len(json); i++ {
+					if json[i] == '*' && i+1 < len(json) && json[i+1] == '/' {
+						blank(i)
+						blank(i + 1)
+						i++
+						break
+					}
+					blank(i)
+				}
+			} else {
+				comma, value = -1, true
+			}
+		case ',':
+			if value {
+				comma = i
+			} else {
+				comma = -1
+			}
+			value = false
+		case '}', ']':
+			if comma != -1 {
+				blank(comma)
+			}
+			comma, value = -1, true
+		case '{', '[', ':':
+			comma, value = -1, false
+		default:
+			comma, value = -1, true
+		}
+	}
+	if out == nil {
+		return json
+	}
+	return string(out)
+}
+
+// GetJSONC searches JSONC for the specified path. It's like Get, which also
+// skips comments and trailing commas, but the Raw of the result has them
+// replaced with spaces, so that it's json. The Index is the position in the
+// original json.
+//
+// A path with modifiers searches a copy of the json without comments, as
+// modifiers expect json.
+func GetJSONC(json, path string) Result {
+	if strings.IndexByte(path, '@') >= 0 {
+		return Get(StripComments(json), path)
+	}
+	res := Get(json, path)
+	if res.Type == JSON {
+		res.Raw = StripComments(res.Raw)
+	}
+	return res
+}
+
+// skipComment returns the position after the "//" or "/* */" comment at
+// json[i], or after the '/' when it doesn't start a comment.
+func skipComment(json string, i int) int {
+	if i+1 < len(json) {
+		switch json[i+1] {
+		case '/':
+			for i += 2; i < len(json) && json[i] != '\n'; i++ {
+			}
+			return i
+		case '*':
+			for i += 2; i+1 < len(json); i++ {
+				if json[i] == '*' && json[i+1] == '/' {
+					return i + 2
+				}
+			}
+			return len(json)
+		}
+	}
+	return i + 1
+}
+
+// ParseJSONC parses JSONC and returns a value. See GetJSONC.
+func ParseJSONC(json string) Result {
+	return Parse(StripComments(json))
+}
+
+// ValidJSONC returns true if the input is valid JSONC, which is json with
+// comments and trailing commas. A trailing comma must follow a value, and a
+// "/* */" comment must be closed.
+func ValidJSONC(json string) bool {
+	_, ok := validpayload(stringBytes(json), 0, true)
+	return ok
+}
+
+// @stripcomments converts JSONC to json. See StripComments.
+//
+//	[1, /* two */ 2,]  ->  [1,           2 ]
+func modStripComments(json, arg string) string {
+	return StripComments(json)
+}
@@ -0,0 +1,99 @@
+package gjson
+
+import "testing"
+
+func TestStripComments(t *testing.T) {
+	json := "{\n" +
+		"  // name of the app\n" +
+		"  \"name\": \"app // not a comment\", /* block\n" +
+		"  comment */ \"tags\": [\"a\", \"b\",],\n" +
+		"  \"url\": \"http://x/*y*/\",\n" +
+		"}"
+	out := StripComments(json)
+	assert(t, len(out) == len(json))
+	assert(t, out == "{\n"+
+		"                    \n"+
+		"  \"name\": \"app // not a comment\",         \n"+
+		"             \"tags\": [\"a\", \"b\" ],\n"+
+		"  \"url\": \"http://x/*y*/\" \n"+
+		"}")
+	assert(t, Valid(out))
+	assert(t, !Valid(json))
+	assert(t, ValidJSONC(json))
+	assert(t, !ValidJSONC(`{"a":1,,}`))
+	assert(t, !ValidJSONC(`{"a":/ 1}`))
+
+	strict := `{"a":[1,2],"b":"/,]"}`
+	out = StripComments(strict)
+	assert(t, out == strict)
+	assert(t, StripComments(`[1, 2, // x`) == `[1, 2,     `)
+	assert(t, StripComments(`"a\"/*"/**/`) == `"a\"/*"    `)
+	assert(t, StripComments(`[1 /* x `) == `[1      `)
+	// a comma without a value before it isn't trailing
+	assert(t, StripComments(`[,]`) == `[,]`)
+	assert(t, StripComments(`{"a":[1,],}`) == `{"a":[1 ] }`)
+}
+
+func TestValidJSONC(t *testing.T) {
+	for _, json := range []string{
+		`[1,2,]`, `{"a":1,}`, "[1, // c\n]", "// lead\n{\"a\":1}",
+		`[1 /* x */, 2]`, `{"a" /* k */ : /* v */ 1 /* , */}`,
+		"{\"a\":1} // end", `[[],{},]`,
+	} {
+		assert(t, ValidJSONC(json))
+		assert(t, !Valid(json))
+	}
+	for _, json := range []string{
+		`[,]`, `{,}`, `[1,,]`, `{"a":1,,}`, `{"a":1} /* open`, `[1 /* x ]`,
+		`[1 / 2]`, `{"a":1}/`, ``, `// only`,
+	} {
+		assert(t, !ValidJSONC(json))
+	}
+}
+
+func TestGetJSONC(t *testing.T) {
+	json := `{
+		/* users */
+		"users": [
+			{"name": "Janet", "age": 31,}, // first
+			{"name": "Carol", "age": 25,},
+		],
+	}`
+	res := GetJSONC(json, "users.#(age<30).name")
+	assert(t, res.String() == "Carol")
+	assert(t, json[res.Index:res.Index+len(res.Raw)] == `"Carol"`)
+	res = GetJSONC(json, "users.0")
+	assert(t, json[res.Index:res.Index+len(res.Raw)] ==
+		`{"name": "Janet", "age": 31,}`)
+	assert(t, res.Raw == `{"name": "Janet", "age": 31 }`)
+	assert(t, GetJSONC(json, "users.#").Int() == 2)
+	assert(t, ParseJSONC(json).Get("users.1.age").Int() == 25)
+	assert(t, ParseJSONC(json).IsObject())
+	assert(t, Get(json, "@stripcomments|@valid|users.1.name").String() ==
+		"Carol")
+	assert(t, Get(json, "@stripcomments|@ugly").Raw ==
+		`{"users":[{"name":"Janet","age":31},{"name":"Carol","age":25}]}`)
+	assert(t, GetJSONC(json, "users|@reverse|0.name").String() == "Carol")
+}
+
+func TestGetComments(t *testing.T) {
+	// Get skips comments, even when they hold keys, values and brackets
+	json := "// {\"a\":0}\n" +
+		`{/* "a":0, */ "a": 1, "b": [1, /* 2, "x", [ */ 3,], // "c": 0` +
+		"\n" + `"c": {"d": /* 0 */ 2}, "e": "/* not a comment */"}`
+	assert(t, Get(json, "a").Int() == 1)
+	assert(t, Get(json, "b.#").Int() == 2)
+	assert(t, Get(json, "b.1").Int() == 3)
+	assert(t, Get(json, "b.#(==3)").Int() == 3)
+	assert(t, Get(json, "c.d").Int() == 2)
+	assert(t, Get(json, "e").String() == "/* not a comment */")
+	assert(t, Get(json, "..d").Raw == `[2]`)
+	assert(t, len(Get(json, "b").Array()) == 2)
+	var keys []string
+	Get(json, "@this").ForEach(func(key, _ Result) bool {
+		keys = append(keys, key.Str)
+		return true
+	})
+	assert(t, len(keys) == 4 && keys[1] == "b" && keys[2] == "c")
+	assert(t, Get(`[1/*x*/,2]`, "0").Raw == "1")
+}
@@ -0,0 +1,262 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gjson
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrMaxDepth is returned when a search nests deeper than MaxDepth.
+	ErrMaxDepth = errors.New("gjson: maximum depth exceeded")
+	// ErrMaxSteps is returned when a search visits more than MaxSteps values.
+	ErrMaxSteps = errors.New("gjson: maximum steps exceeded")
+	// ErrMaxOutput is returned when a search generates more than MaxOutput
+	// bytes of json.
+	ErrMaxOutput = errors.New("gjson: maximum output exceeded")
+	// ErrMaxModifiers is returned when a search calls more than
+	// MaxModifiers modifiers.
+	ErrMaxModifiers = errors.New("gjson: maximum modifiers exceeded")
+)
+
+// Options are the limits and settings for GetWithOptions, for when a path or
+// a document is untrusted. A zero limit means no limit.
+type Options struct {
+	// MaxDepth is the maximum nesting depth of a search. Each object or
+	// array that is descended into, and each nested path, such as in a
+	// query, a multipath, or after a modifier, adds a level.
+	MaxDepth int
+	// MaxSteps is the maximum number of values visited by a search. A
+	// modifier counts as visiting a value for every 16 bytes of its input,
+	// and so does the path of @dig for each value that it's applied to.
+	MaxSteps int
+	// MaxOutput is the maximum number of bytes of json generated by a
+	// search, such as by modifiers, multipaths, literals, and queries that
+	// return all matches.
+	MaxOutput int
+	// MaxModifiers is the maximum number of modifier calls of a search.
+	MaxModifiers int
+	// Context cancels the search when it's done.
+	Context context.Context
+	// CaseInsensitive matches the keys of the path to object keys without
+	// regard to case, like Engine.CaseInsensitive.
+	CaseInsensitive bool
+	// CaseInsensitivePrefix enables the '~' prefix of path keys, like
+	// Engine.CaseInsensitivePrefix.
+	CaseInsensitivePrefix bool
+	// DuplicateKeys is the policy for path keys that match more than one
+	// member of an object, like Engine.DuplicateKeys. It's used instead of
+	// the engine's policy when it's not DuplicateFirst.
+	DuplicateKeys DuplicatePolicy
+}
+
+// modifierStepBytes is the number of bytes of a modifier's input that
+// counts as one step.
+const modifierStepBytes = 16
+
+// ctxCheckSteps is how often, in steps, the context is checked.
+const ctxCheckSteps = 256
+
+// noLimits are the Options of a search without limits.
+var noLimits = &Options{}
+
+// limiter holds the engine and enforces the Options of a search. All methods
+// are safe to call on a nil limiter, which is a search with the default
+// engine and no limits. The engine of a limiter is never nil.
+type limiter struct {
+	engine    *Engine
+	opts      *Options
+	root      string
+	rooted    bool
+	depth     int
+	steps     int
+	output    int
+	modifiers int
+	err       error
+}
+
+func (l *limiter) fail(err error) bool {
+	if l.err == nil {
+		l.err = err
+	}
+	return false
+}
+
+// step counts n visited values and reports whether the search may go on.
+func (l *limiter) step(n int) bool {
+	if l == nil {
+		return true
+	}
+	if l.err != nil {
+		return false
+	}
+	before := l.steps
+	l.steps += n
+	if l.opts.MaxSteps > 0 && l.steps > l.opts.MaxSteps {
+		return l.fail(ErrMaxSteps)
+	}
+	if l.opts.Context != nil &&
+		before/ctxCheckSteps != l.steps/ctxCheckSteps {
+		if err := l.opts.Context.Err(); err != nil {
+			return l.fail(err)
+		}
+	}
+	return true
+}
+
+// enter adds a level of depth and reports whether the search may go on.
+// Each enter must be followed by a leave.
+func (l *limiter) enter() bool {
+	if l == nil {
+		return true
+	}
+	l.depth++
+	if l.opts.MaxDepth > 0 && l.depth > l.opts.MaxDepth {
+		return l.fail(ErrMaxDepth)
+	}
+	return l.err == nil
+}
+
+// nested reports whether the search may go on n levels deeper than the
+// current depth.
+func (l *limiter) nested(n int) bool {
+	if l.opts.MaxDepth > 0 && l.depth+n > l.opts.MaxDepth {
+		return l.fail(ErrMaxDepth)
+	}
+	return l.err == nil
+}
+
+func (l *limiter) leave() {
+	if l != nil {
+		l.depth--
+	}
+}
+
+// emit counts n bytes of generated json and reports whether the search may
+// go on.
+func (l *limiter) emit(n int) bool {
+	if l == nil {
+		return true
+	}
+	l.output += n
+	if l.opts.MaxOutput > 0 && l.output > l.opts.MaxOutput {
+		return l.fail(ErrMaxOutput)
+	}
+	return l.err == nil
+}
+
+// call counts a modifier call with the json input and reports whether the
+// modifier may be called.
+func (l *limiter) call(json string) bool {
+	if l == nil {
+		return true
+	}
+	l.modifiers++
+	if l.opts.MaxModifiers > 0 && l.modifiers > l.opts.MaxModifiers {
+		return l.fail(ErrMaxModifiers)
+	}
+	if l.opts.Context != nil {
+		if err := l.opts.Context.Err(); err != nil {
+			return l.fail(err)
+		}
+	}
+	return l.step(1 + len(json)/modifierStepBytes)
+}
+
+// modifiersDisabled reports whether the modifier syntax is disabled.
+func (l *limiter) modifiersDisabled() bool {
+	if l == nil {
+		return defaultEngine.modifiersDisabled()
+	}
+	return l.engine.modifiersDisabled()
+}
+
+// literalsDisabled reports whether the literal syntax is disabled.
+func (l *limiter) literalsDisabled() bool {
+	return l != nil && l.engine.DisableLiterals
+}
+
+// modifier returns the modifier with the name.
+func (l *limiter) modifier(name string) (ModifierFunc, bool) {
+	if l == nil {
+		return defaultEngine.modifier(name)
+	}
+	return l.engine.modifier(name)
+}
+
+// context returns the context of the search.
+func (l *limiter) context() context.Context {
+	if l == nil || l.opts.Context == nil {
+		return context.Background()
+	}
+	return l.opts.Context
+}
+
+// duplicateKeys returns the policy for keys with duplicates.
+func (l *limiter) duplicateKeys() DuplicatePolicy {
+	switch {
+	case l == nil:
+		return DuplicateFirst
+	case l.opts.DuplicateKeys != DuplicateFirst:
+		return l.opts.DuplicateKeys
+	}
+	return l.engine.DuplicateKeys
+}
+
+// caseInsensitive reports whether keys are matched without regard to case.
+func (l *limiter) caseInsensitive() bool {
+	return l != nil && (l.opts.CaseInsensitive || l.engine.CaseInsensitive)
+}
+
+// foldPrefix reports whether a '~' prefix makes a path key case-insensitive.
+func (l *limiter) foldPrefix() bool {
+	return l != nil &&
+		(l.opts.CaseInsensitivePrefix || l.engine.CaseInsensitivePrefix)
+}
+
+// GetWithOptions searches json for the specified path, like Get, but returns
+// an error when the search goes beyond one of the limits of the options, when
+// the context of the options is done, or when a ModifierFunc fails.
+//
+//	opts := &gjson.Options{MaxDepth: 32, MaxSteps: 100000}
+//	res, err := gjson.GetWithOptions(json, path, opts)
+//	if errors.Is(err, gjson.ErrMaxSteps) {
+//		// the path is too expensive
+//	}
+func GetWithOptions(json, path string, opts *Options) (Result, error) {
+	return withLimits(defaultEngine, opts, func(l *limiter) Result {
+		return getLimit(json, path, l)
+	})
+}
+
+// GetBytesWithOptions searches json for the specified path, like GetBytes.
+// See GetWithOptions.
+func GetBytesWithOptions(json []byte, path string, opts *Options) (Result,
+	error,
+) {
+	return withLimits(defaultEngine, opts, func(l *limiter) Result {
+		return getBytes(json, path, l)
+	})
+}
+
+// withLimits runs a search with an engine and options, which may be nil.
+func withLimits(e *Engine, opts *Options, search func(l *limiter) Result,
+) (Result, error) {
+	if opts == nil {
+		opts = noLimits
+	}
+	if opts.Context != nil {
+		if err := opts.Context.Err(); err != nil {
+			return Result{}, err
+		}
+	}
+	l := &limiter{engine: e, opts: opts}
+	res := search(l)
+	if l.err != nil {
+		return Result{}, l.err
+	}
+	return res, nil
+}
@@ -0,0 +1,76 @@
+package gjson
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGetWithOptions(t *testing.T) {
+	json := `{"a":{"b":{"c":{"d":1}}},"arr":[1,2,3,4,5,6,7,8,9,10],` +
+		`"objs":[{"n":1},{"n":2},{"n":3}]}`
+
+	res, err := GetWithOptions(json, "a.b.c.d", nil)
+	assert(t, err == nil && res.Int() == 1)
+	res, err = GetWithOptions(json, "a.b.c.d", &Options{})
+	assert(t, err == nil && res.Int() == 1)
+	res, err = GetWithOptions(json, "objs.#(n>1)#.n", &Options{
+		MaxDepth: 8, MaxSteps: 100, MaxOutput: 100, MaxModifiers: 1,
+	})
+	assert(t, err == nil && res.Raw == `[2,3]`)
+	assert(t, res.Indexes != nil)
+
+	tests := []struct {
+		path string
+		opts Options
+		err  error
+	}{
+		{"a.b.c.d", Options{MaxDepth: 4}, ErrMaxDepth},
+		{"a.b.c.d", Options{MaxDepth: 5}, nil},
+		{"objs.#(n==3).n", Options{MaxDepth: 3}, ErrMaxDepth},
+		{"{a:{b:{c:a}}}", Options{MaxDepth: 3}, ErrMaxDepth},
+		{"arr.9", Options{MaxSteps: 10}, ErrMaxSteps},
+		{"arr.9", Options{MaxSteps: 13}, nil},
+		{"arr.#(==10)", Options{MaxSteps: 10}, ErrMaxSteps},
+		{"@dig:n", Options{MaxSteps: 5}, ErrMaxSteps},
+		{"[arr,arr,arr]", Options{MaxOutput: 60}, ErrMaxOutput},
+		{"[arr,arr,arr]", Options{MaxOutput: 80}, nil},
+		{"arr.#(>0)#", Options{MaxOutput: 10}, ErrMaxOutput},
+		{"objs.#.n", Options{MaxOutput: 5}, ErrMaxOutput},
+		{"arr|@reverse|@reverse|@reverse", Options{MaxModifiers: 2},
+			ErrMaxModifiers},
+		{"arr|@reverse|@reverse", Options{MaxModifiers: 2}, nil},
+		{"{a:@this|@this,b:@this|@this}", Options{MaxModifiers: 3},
+			ErrMaxModifiers},
+		{"arr.@pretty", Options{MaxOutput: 20}, ErrMaxOutput},
+		{`!"` + strings.Repeat("x", 100) + `"`, Options{MaxOutput: 50},
+			ErrMaxOutput},
+	}
+	for _, tt := range tests {
+		opts := tt.opts
+		res, err := GetWithOptions(json, tt.path, &opts)
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("%s: expected %v, got %v", tt.path, tt.err, err)
+		}
+		if err != nil {
+			assert(t, !res.Exists())
+		} else {
+			assert(t, res.Raw == Get(json, tt.path).Raw)
+		}
+		res, err = GetBytesWithOptions([]byte(json), tt.path, &opts)
+		assert(t, errors.Is(err, tt.err))
+	}
+}
+
+func TestGetWithOptionsContext(t *testing.T) {
+	json := "[" + strings.Repeat(`{"a":1},`, 10000) + `{"a":2}]`
+	ctx, cancel := context.WithCancel(context.Background())
+	res, err := GetWithOptions(json, "#(a==2).a", &Options{Context: ctx})
+	assert(t, err == nil && res.Int() == 2)
+	cancel()
+	_, err = GetWithOptions(json, "#(a==2).a", &Options{Context: ctx})
+	assert(t, errors.Is(err, context.Canceled))
+	_, err = GetWithOptions(`[1]`, "0", &Options{Context: ctx})
+	assert(t, errors.Is(err, context.Canceled))
+}
@@ -0,0 +1,270 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gjson
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrBuiltinModifier is returned when registering a modifier with the name of
+// a built-in modifier.
+var ErrBuiltinModifier = errors.New("gjson: built-in modifier")
+
+// ErrModifierName is returned when registering a modifier with a name that
+// cannot be used in a path.
+var ErrModifierName = errors.New("gjson: invalid modifier name")
+
+// ModifierInfo describes a modifier.
+type ModifierInfo struct {
+	// Name is the name of the modifier, without the '@'.
+	Name string
+	// Description is a short description of what the modifier does.
+	Description string
+	// ArgSchema is a JSON Schema of the modifier's argument, or empty when
+	// the modifier takes no argument or describes it in the Description.
+	ArgSchema string
+	// Builtin is true for the modifiers that come with this package.
+	Builtin bool
+}
+
+// ModifierFunc is a modifier that can report an error. It's called with the
+// current element, the root of the search, and the argument, which is a
+// non-existent result when there's no argument. A json argument, such as in
+// @pretty:{"indent":"  "}, is parsed. A simple argument, such as in
+// @case:upper, is a String with the text of the argument as its Str and Raw.
+//
+// The returned result is used as the json for the rest of the path. When it
+// has no Raw, it's converted to its json form. The error stops the search,
+// and is returned by GetWithOptions and Engine.GetE.
+type ModifierFunc interface {
+	Modify(ctx context.Context, current, root, arg Result) (Result, error)
+}
+
+// ModifyFunc is a function that's a ModifierFunc.
+type ModifyFunc func(ctx context.Context, current, root, arg Result) (Result,
+	error)
+
+// Modify calls fn(ctx, current, root, arg).
+func (fn ModifyFunc) Modify(ctx context.Context, current, root, arg Result,
+) (Result, error) {
+	return fn(ctx, current, root, arg)
+}
+
+// StringModifier is a modifier with the signature of AddModifier, as a
+// ModifierFunc. It's called with the Raw of the current element and of the
+// argument, and never returns an error.
+type StringModifier func(json, arg string) string
+
+// Modify calls fn(current.Raw, arg.Raw).
+func (fn StringModifier) Modify(ctx context.Context, current, root, arg Result,
+) (Result, error) {
+	json := fn(current.Raw, arg.Raw)
+	res := Parse(json)
+	res.Raw = json
+	return res, nil
+}
+
+type modifier struct {
+	info ModifierInfo
+	fn   ModifierFunc
+}
+
+// The registered modifiers are a map that's never changed once it's stored.
+// Changes are made to a copy under the lock, so that lookups are lock-free.
+var (
+	modifiersMu sync.Mutex
+	modifiers   atomic.Value // map[string]modifier
+)
+
+// builtinModifierInfo are the descriptions and argument schemas of the
+// built-in modifiers.
+var builtinModifierInfo = map[string][2]string{
+	"pretty": {"Make the json more human readable.",
+		`{"type":"object","properties":{"sortKeys":{"type":"boolean"},` +
+			`"indent":{"type":"string"},"prefix":{"type":"string"},` +
+			`"width":{"type":"integer"}}}`},
+	"ugly":    {"Remove all whitespace from the json.", ""},
+	"reverse": {"Reverse an array or the members of an object.", ""},
+	"this":    {"Return the current element.", ""},
+	"flatten": {"Flatten an array.",
+		`{"type":"object","properties":{"deep":{"type":"boolean"}}}`},
+	"join": {"Join multiple objects into a single object.",
+		`{"type":"object","properties":{"preserve":{"type":"boolean"}}}`},
+	"valid":   {"Ensure the json document is valid.", ""},
+	"keys":    {"Return an array of the keys of an object.", ""},
+	"values":  {"Return an array of the values of an object.", ""},
+	"tostr":   {"Convert json to a string. Wraps a json string.", ""},
+	"fromstr": {"Convert a string from json. Unwraps a json string.", ""},
+	"group":   {"Group arrays of objects.", ""},
+	"dig": {"Search for a value without providing its entire path.",
+		`{"type":"string"}`},
+	"date": {"Reformat, truncate, shift the time zone of, or compare a " +
+		"timestamp.",
+		`{"type":["string","object"],"properties":{` +
+			`"layout":{"type":"string"},"format":{"type":"string"},` +
+			`"tz":{"type":"string"},"truncate":{"type":"string"},` +
+			`"add":{"type":"string"},` +
+			`"before":{"type":["string","number"]},` +
+			`"after":{"type":["string","number"]},` +
+			`"equal":{"type":["string","number"]}}}`},
+	"canonical": {"Convert json to the RFC 8785 canonical form.", ""},
+	"stripcomments": {"Convert JSONC to json by replacing comments and " +
+		"trailing commas with spaces.", ""},
+	"dupes": {"Return the paths of object keys that are duplicates of an " +
+		"earlier key.", ""},
+}
+
+// initModifiers registers the built-in modifiers.
+func initModifiers() {
+	mods := make(map[string]modifier, len(builtinModifiers))
+	for name, fn := range builtinModifiers {
+		info := builtinModifierInfo[name]
+		mods[name] = modifier{ModifierInfo{
+			Name: name, Description: info[0], ArgSchema: info[1],
+			Builtin: true,
+		}, StringModifier(fn)}
+	}
+	modifiers.Store(mods)
+}
+
+func loadModifiers() map[string]modifier {
+	mods, _ := modifiers.Load().(map[string]modifier)
+	return mods
+}
+
+// lookupModifier returns the registered modifier with the name.
+func lookupModifier(name string) (ModifierFunc, bool) {
+	mod, ok := loadModifiers()[name]
+	return mod.fn, ok
+}
+
+// updateModifiers changes a copy of the registered modifiers and stores it.
+func updateModifiers(update func(mods map[string]modifier) bool) bool {
+	modifiersMu.Lock()
+	defer modifiersMu.Unlock()
+	old := loadModifiers()
+	mods := make(map[string]modifier, len(old)+1)
+	for name, mod := range old {
+		mods[name] = mod
+	}
+	if !update(mods) {
+		return false
+	}
+	modifiers.Store(mods)
+	return true
+}
+
+// AddModifier binds a custom modifier command to the GJSON syntax, replacing
+// any modifier with the same name, including a built-in modifier.
+// It's safe to call concurrently with searches.
+func AddModifier(name string, fn func(json, arg string) string) {
+	updateModifiers(func(mods map[string]modifier) bool {
+		mods[name] = modifier{ModifierInfo{Name: name}, StringModifier(fn)}
+		return true
+	})
+}
+
+// RegisterModifier binds a custom modifier command to the GJSON syntax. It's
+// like AddModifier, but returns ErrBuiltinModifier instead of replacing a
+// built-in modifier, and ErrModifierName when the name has a character that
+// cannot be used in a path, such as '.', '|', or ':'.
+// It's safe to call concurrently with searches.
+//
+//	gjson.RegisterModifier(gjson.ModifierInfo{
+//		Name:        "upper",
+//		Description: "Make the json upper case.",
+//	}, func(json, arg string) string {
+//		return strings.ToUpper(json)
+//	})
+func RegisterModifier(info ModifierInfo, fn func(json, arg string) string,
+) error {
+	if fn == nil {
+		return ErrModifierName
+	}
+	return RegisterModifierFunc(info, StringModifier(fn))
+}
+
+// RegisterModifierFunc binds a custom ModifierFunc to the GJSON syntax. See
+// RegisterModifier.
+//
+//	gjson.RegisterModifierFunc(gjson.ModifierInfo{Name: "len"},
+//		gjson.ModifyFunc(func(ctx context.Context, current, root,
+//			arg gjson.Result) (gjson.Result, error) {
+//			if !current.IsArray() {
+//				return gjson.Result{}, errors.New("len: not an array")
+//			}
+//			n := len(current.Array())
+//			return gjson.Result{Type: gjson.Number, Num: float64(n)}, nil
+//		}))
+func RegisterModifierFunc(info ModifierInfo, fn ModifierFunc) error {
+	if info.Name == "" || fn == nil ||
+		strings.ContainsAny(info.Name, ".|:@#*?!\\[]{}()\"") {
+		return ErrModifierName
+	}
+	if _, ok := builtinModifiers[info.Name]; ok {
+		return ErrBuiltinModifier
+	}
+	info.Builtin = false
+	updateModifiers(func(mods map[string]modifier) bool {
+		mods[info.Name] = modifier{info, fn}
+		return true
+	})
+	return nil
+}
+
+// UnregisterModifier removes a custom modifier. A built-in modifier that was
+// replaced by AddModifier is restored. It returns false when there's no
+// such custom modifier.
+// It's safe to call concurrently with searches.
+func UnregisterModifier(name string) bool {
+	return updateModifiers(func(mods map[string]modifier) bool {
+		mod, ok := mods[name]
+		if !ok || mod.info.Builtin {
+			return false
+		}
+		delete(mods, name)
+		if fn, ok := builtinModifiers[name]; ok {
+			info := builtinModifierInfo[name]
+			mods[name] = modifier{ModifierInfo{
+				Name: name, Description: info[0], ArgSchema: info[1],
+				Builtin: true,
+			}, StringModifier(fn)}
+		}
+		return true
+	})
+}
+
+// Modifiers returns the registered modifiers, ordered by name.
+func Modifiers() []ModifierInfo {
+	mods := loadModifiers()
+	infos := make([]ModifierInfo, 0, len(mods))
+	for _, mod := range mods {
+		infos = append(infos, mod.info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// ModifierExists returns true when the specified modifier exists. When fn is
+// not nil, the modifier must also be that function.
+func ModifierExists(name string, fn func(json, arg string) string) bool {
+	mfn, ok := lookupModifier(name)
+	return ok && (fn == nil || sameModifier(fn, mfn))
+}
+
+// sameModifier reports whether a modifier is a StringModifier with the same
+// code as fn.
+func sameModifier(fn func(json, arg string) string, mod ModifierFunc) bool {
+	sfn, ok := mod.(StringModifier)
+	return ok && reflect.ValueOf(fn).Pointer() == reflect.ValueOf(sfn).Pointer()
+}
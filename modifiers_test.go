@@ -0,0 +1,198 @@
+package gjson
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func modUpper(json, arg string) string {
+	return strings.ToUpper(json)
+}
+
+func TestRegisterModifier(t *testing.T) {
+	defer UnregisterModifier("upper")
+	info := ModifierInfo{Name: "upper", Description: "Upper case.",
+		Builtin: true}
+	assert(t, RegisterModifier(info, modUpper) == nil)
+	assert(t, Get(`["a"]`, "@upper").Raw == `["A"]`)
+	assert(t, ModifierExists("upper", nil))
+	assert(t, ModifierExists("upper", modUpper))
+	assert(t, !ModifierExists("upper", modPretty))
+	assert(t, ModifierExists("pretty", modPretty))
+	assert(t, !ModifierExists("nope", nil))
+
+	err := RegisterModifier(ModifierInfo{Name: "pretty"}, modUpper)
+	assert(t, errors.Is(err, ErrBuiltinModifier))
+	for _, name := range []string{"", "a.b", "a|b", "a:b", "a b\""} {
+		err := RegisterModifier(ModifierInfo{Name: name}, modUpper)
+		assert(t, errors.Is(err, ErrModifierName))
+	}
+	assert(t, errors.Is(RegisterModifier(ModifierInfo{Name: "x"}, nil),
+		ErrModifierName))
+	assert(t, Get(`[1]`, "@pretty").Raw == "[1]\n")
+
+	var found bool
+	infos := Modifiers()
+	for i, info := range infos {
+		if i > 0 {
+			assert(t, infos[i-1].Name < info.Name)
+		}
+		switch info.Name {
+		case "upper":
+			assert(t, !info.Builtin && info.Description == "Upper case.")
+			found = true
+		case "pretty":
+			assert(t, info.Builtin && info.Description != "" &&
+				Valid(info.ArgSchema))
+		default:
+			assert(t, !info.Builtin || info.Description != "")
+		}
+	}
+	assert(t, found)
+
+	assert(t, UnregisterModifier("upper"))
+	assert(t, !UnregisterModifier("upper"))
+	assert(t, !UnregisterModifier("pretty"))
+	assert(t, !Get(`["a"]`, "@upper").Exists())
+	assert(t, ModifierExists("pretty", nil))
+
+	// a replaced built-in is restored when unregistered
+	AddModifier("reverse", modUpper)
+	assert(t, Get(`["a","b"]`, "@reverse").Raw == `["A","B"]`)
+	assert(t, UnregisterModifier("reverse"))
+	assert(t, Get(`["a","b"]`, "@reverse").Raw == `["b","a"]`)
+	assert(t, ModifierExists("reverse", modReverse))
+}
+
+func TestModifierRegistryConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		name := "concurrent" + strconv.Itoa(i)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				RegisterModifier(ModifierInfo{Name: name}, modUpper)
+				UnregisterModifier(name)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				Get(`["a"]`, "@"+name)
+				assert(t, Get(`["a","b"]`, "@reverse").Raw == `["b","a"]`)
+				Modifiers()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestModifierFunc(t *testing.T) {
+	errNotArray := errors.New("not an array")
+	var e Engine
+	e.AddModifierFunc("len", ModifyFunc(func(ctx context.Context, current,
+		root, arg Result) (Result, error) {
+		if !current.IsArray() {
+			return Result{}, errNotArray
+		}
+		return Result{Type: Number, Num: float64(len(current.Array()))}, nil
+	}))
+	e.AddModifierFunc("root", ModifyFunc(func(ctx context.Context, current,
+		root, arg Result) (Result, error) {
+		return root, nil
+	}))
+	e.AddModifierFunc("arg", ModifyFunc(func(ctx context.Context, current,
+		root, arg Result) (Result, error) {
+		if !arg.Exists() {
+			return Result{Type: String, Str: "none"}, nil
+		}
+		return Result{Type: String, Str: arg.Type.String() + ":" + arg.Str +
+			":" + arg.Get("a").String()}, nil
+	}))
+	e.AddModifierFunc("ctx", ModifyFunc(func(ctx context.Context, current,
+		root, arg Result) (Result, error) {
+		return current, ctx.Err()
+	}))
+
+	json := `{"a":[1,2,3],"b":{"c":"d"}}`
+	assert(t, e.Get(json, "a.@len").Int() == 3)
+	assert(t, e.Get(json, "a|@len").Raw == "3")
+	assert(t, e.Get(json, "{n:a.@len}").Raw == `{"n":3}`)
+	assert(t, !e.Get(json, "b.@len").Exists())
+	_, err := e.GetE(json, "b.@len")
+	assert(t, errors.Is(err, errNotArray))
+	_, err = e.GetE(json, "[a.@len,b.@len]")
+	assert(t, errors.Is(err, errNotArray))
+
+	assert(t, e.Get(json, "b.@root.b.c").String() == "d")
+	assert(t, e.Get(json, "a|@reverse|@root.a.0").Int() == 1)
+	assert(t, e.Get(json, "b.c.@root").Raw == json)
+
+	assert(t, e.Get(json, "@arg").String() == "none")
+	assert(t, e.Get(json, "@arg:upper").String() == "String:upper:")
+	assert(t, e.Get(json, `@arg:"x"`).String() == "String:x:")
+	assert(t, e.Get(json, `@arg:{"a":1}`).String() == "JSON::1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e.Options = &Options{Context: ctx}
+	res, err := e.GetE(json, "b|@ctx.c")
+	assert(t, err == nil && res.String() == "d")
+	cancel()
+	_, err = e.GetE(json, "b|@ctx")
+	assert(t, errors.Is(err, context.Canceled))
+	e.Options = nil
+
+	// string modifiers are adapted
+	var sm ModifierFunc = StringModifier(modUpper)
+	res, err = sm.Modify(context.Background(), Parse(`"a"`), Result{},
+		Result{})
+	assert(t, err == nil && res.String() == "A" && res.Raw == `"A"`)
+	e.AddModifier("upper", modUpper)
+	assert(t, e.ModifierExists("upper", modUpper))
+	assert(t, !e.ModifierExists("len", modUpper))
+	assert(t, e.Get(json, "b|@upper").Raw == `{"C":"D"}`)
+}
+
+func TestRegisterModifierFunc(t *testing.T) {
+	defer UnregisterModifier("fail")
+	defer UnregisterModifier("root")
+	errFail := errors.New("fail")
+	assert(t, RegisterModifierFunc(ModifierInfo{Name: "fail"},
+		ModifyFunc(func(ctx context.Context, current, root, arg Result,
+		) (Result, error) {
+			if arg.Str == "no" {
+				return current, nil
+			}
+			return Result{}, errFail
+		})) == nil)
+	assert(t, errors.Is(RegisterModifierFunc(ModifierInfo{Name: "fail"}, nil),
+		ErrModifierName))
+	assert(t, ModifierExists("fail", nil))
+	assert(t, !ModifierExists("fail", modUpper))
+	assert(t, !Get(`[1]`, "@fail").Exists())
+	assert(t, !Get(`[1]`, "[0,@fail]").Exists())
+	assert(t, Get(`[1]`, "@fail:no").Raw == `[1]`)
+	_, err := GetWithOptions(`[1]`, "@fail", nil)
+	assert(t, errors.Is(err, errFail))
+	assert(t, Get(`{"a":[1]}`, "a|@reverse").Raw == `[1]`)
+
+	// GetBytes and GetManyBytes search like Get
+	assert(t, RegisterModifierFunc(ModifierInfo{Name: "root"},
+		ModifyFunc(func(ctx context.Context, current, root, arg Result,
+		) (Result, error) {
+			return root, nil
+		})) == nil)
+	doc := `{"a":{"b":1}}`
+	assert(t, Get(doc, "a.@root").Raw == doc)
+	assert(t, GetBytes([]byte(doc), "a.@root").Raw == doc)
+	assert(t, GetManyBytes([]byte(doc), "a.@root")[0].Raw == doc)
+	assert(t, !GetBytes([]byte(doc), "[a,@fail]").Exists())
+	assert(t, !GetManyBytes([]byte(doc), "[a,@fail]")[0].Exists())
+	_, err = GetBytesWithOptions([]byte(doc), "[a,@fail]", nil)
+	assert(t, errors.Is(err, errFail))
+}
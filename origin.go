@@ -0,0 +1,190 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gjson
+
+import "sort"
+
+// HasOrigin reports whether the result is a slice of json at its Index, which
+// is when Path and Span can find it. The param 'json' must be the original
+// JSON used when calling Get.
+//
+// A computed result, such as the output of a modifier, a multipath, or a
+// path with '#', has no origin, though its elements may have one, through
+// Indexes. The values kept by @reverse, @flatten, @values, and @this have
+// the origin of where they came from.
+func (t Result) HasOrigin(json string) bool {
+	return rawAt(json, t.Index, t.Raw)
+}
+
+// span is a slice of the original json at origin, which is at
+// Raw[start:end] of a computed result.
+type span struct{ start, end, origin int }
+
+// origins returns the slices of the original json in the raw json of t,
+// which are the elements of t with an index, in order.
+func (t Result) origins() []span {
+	var spans []span
+	elems := Result{Type: t.Type, Raw: t.Raw}
+	var i int
+	elems.ForEach(func(_, value Result) bool {
+		if i < len(t.Indexes) && t.Indexes[i] != 0 {
+			spans = append(spans, span{value.Index,
+				value.Index + len(value.Raw), t.Indexes[i]})
+		}
+		i++
+		return true
+	})
+	return spans
+}
+
+// appendSpans appends the slices of json in res, which is added to a
+// computed result at pos. Either res is a slice of json, or the slices in it
+// are its elements with an index, or its own spans when it has them.
+func appendSpans(spans []span, json string, res Result, rspans []span,
+	pos int,
+) []span {
+	if index := rawIndex(json, res); index != 0 {
+		return append(spans, span{pos, pos + len(res.Raw), index})
+	}
+	if rspans == nil && res.Indexes != nil {
+		rspans = res.origins()
+	}
+	for _, s := range rspans {
+		spans = append(spans, span{pos + s.start, pos + s.end, s.origin})
+	}
+	return spans
+}
+
+// remap returns a result found in the raw json of t, which was computed, with
+// the indexes of the elements of t, so that a result from within an element
+// has the index of where the element came from.
+func (t Result) remap(r Result) Result {
+	r, rspans := remapSpans(t.Raw, t.origins(), r)
+	if rspans != nil && r.Indexes == nil {
+		// a computed value, such as the output of @this, keeps the
+		// indexes of its elements
+		r.Indexes = spanIndexes(r, rspans)
+	}
+	return r
+}
+
+// spanIndexes returns the indexes of the elements of r that are one of the
+// spans, or nil when none are.
+func spanIndexes(r Result, spans []span) []int {
+	var indexes []int
+	var found bool
+	r.ForEach(func(_, value Result) bool {
+		var index int
+		j := sort.Search(len(spans), func(j int) bool {
+			return spans[j].start >= value.Index
+		})
+		if j < len(spans) && spans[j].start == value.Index &&
+			spans[j].end == value.Index+len(value.Raw) {
+			index, found = spans[j].origin, true
+		}
+		indexes = append(indexes, index)
+		return true
+	})
+	if !found {
+		return nil
+	}
+	return indexes
+}
+
+// remapSpans is like remap for a computed raw json with the slices of the
+// original json in it. The slices within r are also returned when r is a
+// computed value of the raw json.
+func remapSpans(raw string, spans []span, r Result) (Result, []span) {
+	origin := func(index int, value string) int {
+		if !rawAt(raw, index, value) {
+			return 0
+		}
+		// the spans are in order, so the value can only be in the last one
+		// that starts at or before it
+		j := sort.Search(len(spans), func(j int) bool {
+			return spans[j].start > index
+		}) - 1
+		if j >= 0 && index+len(value) <= spans[j].end {
+			return spans[j].origin + index - spans[j].start
+		}
+		return 0
+	}
+	if r.Indexes != nil {
+		indexes := make([]int, 0, len(r.Indexes))
+		r.ForEach(func(_, value Result) bool {
+			indexes = append(indexes, origin(value.Index, value.Raw))
+			return true
+		})
+		r.Indexes = indexes
+	}
+	var rspans []span
+	if index := origin(r.Index, r.Raw); index != 0 {
+		r.Index = index
+		return r, nil
+	}
+	if rawAt(raw, r.Index, r.Raw) {
+		// a computed value keeps the slices in it
+		for _, s := range spans {
+			if s.start >= r.Index && s.end <= r.Index+len(r.Raw) {
+				rspans = append(rspans, span{s.start - r.Index,
+					s.end - r.Index, s.origin})
+			}
+		}
+	}
+	r.Index = 0
+	return r, rspans
+}
+
+// modifierIndexes returns where the elements of the output of the built-in
+// @reverse, @flatten, @values, and @this are in their input json, so that
+// the values they keep have an origin. It's nil for any other modifier.
+func modifierIndexes(fn ModifierFunc, json, arg string) []int {
+	res := Parse(json)
+	if !res.IsArray() && !res.IsObject() {
+		return nil
+	}
+	if i := sliceIndex(json, res.Raw); i > 0 {
+		res.Index = i
+	}
+	var indexes []int
+	add := func(_, value Result) bool {
+		indexes = append(indexes, value.Index)
+		return true
+	}
+	switch {
+	case sameModifier(modThis, fn), sameModifier(modValues, fn):
+		res.ForEach(add)
+	case sameModifier(modReverse, fn):
+		res.ForEach(add)
+		for i, j := 0, len(indexes)-1; i < j; i, j = i+1, j-1 {
+			indexes[i], indexes[j] = indexes[j], indexes[i]
+		}
+	case sameModifier(modFlatten, fn):
+		if !res.IsArray() {
+			return nil
+		}
+		var deep bool
+		Parse(arg).ForEach(func(key, value Result) bool {
+			if key.String() == "deep" {
+				deep = value.Bool()
+			}
+			return true
+		})
+		var flatten func(_, value Result) bool
+		flatten = func(_, value Result) bool {
+			if value.IsArray() {
+				if deep {
+					value.ForEach(flatten)
+				} else {
+					value.ForEach(add)
+				}
+				return true
+			}
+			return add(Result{}, value)
+		}
+		res.ForEach(flatten)
+	}
+	return indexes
+}
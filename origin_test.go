@@ -0,0 +1,83 @@
+package gjson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOrigin(t *testing.T) {
+	tests := []struct {
+		path   string
+		origin string // the path of the result, or "" when it's computed
+		paths  string // the paths of the elements
+	}{
+		{"friends|1|age", "friends.1.age", ""},
+		{"friends.@this.0", "friends.0", ""},
+		{"@this.friends.0.first", "friends.0.first", ""},
+		{"name|@this", "name", ""},
+		{"friends.#(age>45)#|0", "friends.1", ""},
+		{"friends.#(age>45)#|#.first", "",
+			"friends.1.first friends.2.first"},
+		{"friends.#.first|1", "friends.1.first", ""},
+		{"[name.first,friends.0.first,!true]", "",
+			"name.first friends.0.first "},
+		{"{name.first,a:friends.1}", "", "name.first friends.1"},
+		{"[name.first,friends.0.first]|1", "friends.0.first", ""},
+		{"{name.first,a:friends.1}.a.age", "friends.1.age", ""},
+		{"{a:friends.1}|a|nets|1", "friends.1.nets.1", ""},
+		{"{a:{b:friends.1}}.a.b.first", "friends.1.first", ""},
+		{"[[name.first,[friends.1]]]|0|1|0|last", "friends.1.last", ""},
+		{"{x:{a:{b:friends.1}}.a}.x.b.age", "friends.1.age", ""},
+		{"{a:{b:friends.1}}.a|@this.b.nets", "friends.1.nets", ""},
+		{"{a:{b:friends.#.first}}.a.b|1", "friends.1.first", ""},
+		{`fav\.movie|@this`, `fav\.movie`, ""},
+		{"[friends.#(age>45)#.last]", "", ""},
+
+		// the values kept by a modifier
+		{"friends|@reverse|0", "friends.2", ""},
+		{"friends|@reverse|0.first", "friends.2.first", ""},
+		{"friends.@reverse.1.age", "friends.1.age", ""},
+		{"friends.#.first|@reverse", "",
+			"friends.2.first friends.1.first friends.0.first"},
+		{"friends.#.first|@this", "",
+			"friends.0.first friends.1.first friends.2.first"},
+		{"friends.#.nets|@flatten", "", "friends.0.nets.0 friends.0.nets.1 " +
+			"friends.0.nets.2 friends.1.nets.0 friends.1.nets.1 " +
+			"friends.2.nets.0 friends.2.nets.1"},
+		{"friends.#.nets|@flatten|@reverse|0", "friends.2.nets.1", ""},
+		{"name|@values", "", "name.first name.last"},
+		{"name|@reverse|last", "name.last", ""},
+
+		// computed
+		{"name|@keys", "", ""},
+		{"friends.#.age|@reverse|@pretty", "", ""},
+		{"friends.#", "", ""},
+		{"friends.#.nets.#", "", "  "},
+		{"friends.#.[first,age]", "", "  "},
+	}
+	for _, tt := range tests {
+		res := Get(readmeJSON, tt.path)
+		if !res.Exists() {
+			t.Fatalf("%q: missing", tt.path)
+		}
+		if path := res.Path(readmeJSON); path != tt.origin {
+			t.Fatalf("%q: expected %q, got %q", tt.path, tt.origin, path)
+		}
+		assert(t, res.HasOrigin(readmeJSON) == (tt.origin != ""))
+		paths := strings.Join(res.Paths(readmeJSON), " ")
+		if paths != tt.paths {
+			t.Fatalf("%q: expected paths %q, got %q", tt.path, tt.paths, paths)
+		}
+		if tt.origin != "" {
+			assert(t, Get(readmeJSON, tt.origin).Raw == res.Raw)
+		}
+	}
+
+	// a result from a result
+	friends := Get(readmeJSON, "friends")
+	assert(t, friends.Get("1|@this.age").Path(readmeJSON) == "friends.1.age")
+	assert(t, friends.Get("[0.first]").Paths(readmeJSON)[0] ==
+		"friends.0.first")
+	assert(t, friends.Get("@reverse.0").Path(readmeJSON) == "friends.2")
+	assert(t, !friends.Get("@reverse").HasOrigin(readmeJSON))
+}
@@ -0,0 +1,345 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gjson
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Errors returned by ApplyPatch, wrapped in a PatchError.
+var (
+	// ErrInvalidPatch is returned when the patch or document is malformed.
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrPathNotFound is returned when a path or from location does not
+	// exist in the document.
+	ErrPathNotFound = errors.New("path not found")
+	// ErrTestFailed is returned when a "test" operation does not match.
+	ErrTestFailed = errors.New("test failed")
+)
+
+// PatchError records a failed JSON Patch operation.
+type PatchError struct {
+	Index int    // the index of the operation in the patch
+	Op    string // the operation, such as "add"
+	Path  string // the JSON Pointer of the operation
+	Err   error  // the reason the operation failed, such as ErrTestFailed
+}
+
+func (e *PatchError) Error() string {
+	if e.Index < 0 {
+		return "gjson: patch: " + e.Err.Error()
+	}
+	return "gjson: patch operation " + strconv.Itoa(e.Index) + " (" + e.Op +
+		" " + strconv.Quote(e.Path) + "): " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyPatch applies an RFC 6902 JSON Patch to a json document, such as one
+// returned by Patch. The "add", "remove", "replace", "move", "copy" and
+// "test" operations are supported.
+//
+//	gjson.ApplyPatch(`{"a":1,"b":2}`, `[{"op":"replace","path":"/a","value":3}]`)
+//	// {"a":3,"b":2}
+//
+// Each operation is spliced into the bytes of the document, so formatting,
+// key order and number spelling of the untouched parts are preserved.
+//
+// The patch is atomic. If any operation fails, then no changes are returned
+// and the error is a PatchError.
+func ApplyPatch(doc, patch string) (string, error) {
+	if !Valid(doc) {
+		return "", &PatchError{Index: -1, Err: errors.New("invalid document")}
+	}
+	ops := Parse(patch)
+	if !Valid(patch) || !ops.IsArray() {
+		return "", &PatchError{Index: -1, Err: ErrInvalidPatch}
+	}
+	var err error
+	var i int
+	ops.ForEach(func(_, op Result) bool {
+		doc, err = applyPatchOp(doc, op)
+		if err != nil {
+			err = &PatchError{
+				Index: i,
+				Op:    op.Get("op").String(),
+				Path:  op.Get("path").String(),
+				Err:   err,
+			}
+			return false
+		}
+		i++
+		return true
+	})
+	if err != nil {
+		return "", err
+	}
+	return doc, nil
+}
+
+func applyPatchOp(doc string, op Result) (string, error) {
+	if !op.IsObject() {
+		return "", ErrInvalidPatch
+	}
+	path := op.Get("path")
+	if path.Type != String {
+		return "", ErrInvalidPatch
+	}
+	tokens, err := pointerTokens(path.Str)
+	if err != nil {
+		return "", err
+	}
+	value := op.Get("value")
+	switch op.Get("op").String() {
+	case "add":
+		if !value.Exists() {
+			return "", ErrInvalidPatch
+		}
+		return patchAdd(doc, tokens, value.Raw)
+	case "remove":
+		return patchRemove(doc, tokens)
+	case "replace":
+		if !value.Exists() {
+			return "", ErrInvalidPatch
+		}
+		target, err := locatePointer(doc, tokens)
+		if err != nil {
+			return "", err
+		}
+		return splice(doc, target.Index, target.Index+len(target.Raw),
+			value.Raw), nil
+	case "test":
+		if !value.Exists() {
+			return "", ErrInvalidPatch
+		}
+		target, err := locatePointer(doc, tokens)
+		if err != nil {
+			return "", err
+		}
+		if !target.Equal(value) {
+			return "", ErrTestFailed
+		}
+		return doc, nil
+	case "move", "copy":
+		from := op.Get("from")
+		if from.Type != String {
+			return "", ErrInvalidPatch
+		}
+		ftokens, err := pointerTokens(from.Str)
+		if err != nil {
+			return "", err
+		}
+		source, err := locatePointer(doc, ftokens)
+		if err != nil {
+			return "", err
+		}
+		raw := source.Raw
+		if op.Get("op").String() == "move" {
+			if path.Str == from.Str {
+				return doc, nil
+			}
+			if strings.HasPrefix(path.Str, from.Str+"/") {
+				// cannot move a value into one of its children
+				return "", ErrInvalidPatch
+			}
+			if doc, err = patchRemove(doc, ftokens); err != nil {
+				return "", err
+			}
+		}
+		return patchAdd(doc, tokens, raw)
+	}
+	return "", ErrInvalidPatch
+}
+
+// pointerTokens splits an RFC 6901 JSON Pointer into its unescaped
+// reference tokens.
+func pointerTokens(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if ptr[0] != '/' {
+		return nil, ErrInvalidPatch
+	}
+	tokens := strings.Split(ptr[1:], "/")
+	for i, token := range tokens {
+		if strings.IndexByte(token, '~') != -1 {
+			token = strings.Replace(token, "~1", "/", -1)
+			token = strings.Replace(token, "~0", "~", -1)
+			tokens[i] = token
+		}
+	}
+	return tokens, nil
+}
+
+// patchRoot returns the root value of the document with its Raw limited to
+// the value itself.
+func patchRoot(doc string) Result {
+	root := Parse(doc)
+	if root.Type == JSON {
+		root.Raw = squash(root.Raw)
+	}
+	return root
+}
+
+// patchMember is a member of an object or an element of an array, as found
+// by findMember. The Index of each Result is the offset in the document.
+type patchMember struct {
+	key   Result // the key of an object member
+	value Result // the value, which does not exist when not found
+	count int    // the number of members
+	index int    // the position of value
+	last  Result // the value of the last member
+}
+
+// findMember looks up the object key, or array index, of a container. The
+// first member of duplicate object keys is found, as with Get. The "-" array
+// index, and the index directly after the last element, are found at the end
+// of the array, without a value.
+func findMember(parent Result, token string) (m patchMember, err error) {
+	isArray := parent.IsArray()
+	idx := -1
+	if isArray {
+		if token != "-" {
+			if len(token) > 1 && token[0] == '0' {
+				return m, ErrPathNotFound
+			}
+			n, ok := parseUint(token)
+			if !ok {
+				return m, ErrPathNotFound
+			}
+			idx = int(n)
+		}
+	} else if !parent.IsObject() {
+		return m, ErrPathNotFound
+	}
+	m.index = -1
+	parent.ForEach(func(key, value Result) bool {
+		if m.index == -1 {
+			if (isArray && idx == m.count) || (!isArray && key.Str == token) {
+				m.key, m.value, m.index = key, value, m.count
+			}
+		}
+		m.last = value
+		m.count++
+		return true
+	})
+	if isArray && m.index == -1 && (idx == -1 || idx == m.count) {
+		m.index = m.count
+	}
+	return m, nil
+}
+
+// locatePointer returns the value at the pointer. The Index of the value is
+// the offset in the document.
+func locatePointer(doc string, tokens []string) (Result, error) {
+	cur := patchRoot(doc)
+	for _, token := range tokens {
+		m, err := findMember(cur, token)
+		if err != nil {
+			return Result{}, err
+		}
+		if !m.value.Exists() {
+			return Result{}, ErrPathNotFound
+		}
+		cur = m.value
+	}
+	return cur, nil
+}
+
+func splice(doc string, start, end int, text string) string {
+	return doc[:start] + text + doc[end:]
+}
+
+func patchAdd(doc string, tokens []string, raw string) (string, error) {
+	if len(tokens) == 0 {
+		// replace the whole document
+		return raw, nil
+	}
+	parent, err := locatePointer(doc, tokens[:len(tokens)-1])
+	if err != nil {
+		return "", err
+	}
+	token := tokens[len(tokens)-1]
+	m, err := findMember(parent, token)
+	if err != nil {
+		return "", err
+	}
+	if parent.IsObject() {
+		if m.value.Exists() {
+			// replace the existing member
+			return splice(doc, m.value.Index,
+				m.value.Index+len(m.value.Raw), raw), nil
+		}
+		member := string(AppendJSONString(nil, token)) + ":" + raw
+		return insertMember(doc, parent, m, member), nil
+	}
+	switch {
+	case m.value.Exists():
+		// insert before the existing element
+		return splice(doc, m.value.Index, m.value.Index, raw+","), nil
+	case m.index == m.count:
+		return insertMember(doc, parent, m, raw), nil
+	}
+	return "", ErrPathNotFound
+}
+
+// insertMember inserts a member after the last member of a container.
+func insertMember(doc string, parent Result, m patchMember,
+	member string) string {
+	if m.count == 0 {
+		return splice(doc, parent.Index+1, parent.Index+1, member)
+	}
+	end := m.last.Index + len(m.last.Raw)
+	return splice(doc, end, end, ","+member)
+}
+
+func patchRemove(doc string, tokens []string) (string, error) {
+	if len(tokens) == 0 {
+		// the root cannot be removed
+		return "", ErrInvalidPatch
+	}
+	parent, err := locatePointer(doc, tokens[:len(tokens)-1])
+	if err != nil {
+		return "", err
+	}
+	m, err := findMember(parent, tokens[len(tokens)-1])
+	if err != nil {
+		return "", err
+	}
+	if !m.value.Exists() {
+		return "", ErrPathNotFound
+	}
+	start := m.value.Index
+	if parent.IsObject() {
+		start = m.key.Index
+	}
+	end := m.value.Index + len(m.value.Raw)
+	// remove a separating comma, preferably the one that follows
+	i := end
+	for i < len(doc) && doc[i] <= ' ' {
+		i++
+	}
+	if i < len(doc) && doc[i] == ',' {
+		i++
+		for i < len(doc) && doc[i] <= ' ' {
+			i++
+		}
+		end = i
+	} else {
+		i = start - 1
+		for i > parent.Index && doc[i] <= ' ' {
+			i--
+		}
+		if doc[i] == ',' {
+			start = i
+		}
+	}
+	return splice(doc, start, end, ""), nil
+}
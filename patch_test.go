@@ -0,0 +1,123 @@
+package gjson
+
+import (
+	"errors"
+	"testing"
+)
+
+func testPatch(t *testing.T, doc, patch, expect string) {
+	t.Helper()
+	out, err := ApplyPatch(doc, patch)
+	if err != nil {
+		t.Fatalf("%s: %v", patch, err)
+	}
+	if out != expect {
+		t.Fatalf("%s: expected %s, got %s", patch, expect, out)
+	}
+}
+
+func testPatchError(t *testing.T, doc, patch string, target error) {
+	t.Helper()
+	out, err := ApplyPatch(doc, patch)
+	var perr *PatchError
+	if out != "" || !errors.As(err, &perr) || !errors.Is(err, target) {
+		t.Fatalf("%s: expected %v, got %q, %v", patch, target, out, err)
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	// RFC 6902, Appendix A
+	testPatch(t, `{"foo":"bar"}`,
+		`[{"op":"add","path":"/baz","value":"qux"}]`,
+		`{"foo":"bar","baz":"qux"}`)
+	testPatch(t, `{"foo":["bar","baz"]}`,
+		`[{"op":"add","path":"/foo/1","value":"qux"}]`,
+		`{"foo":["bar","qux","baz"]}`)
+	testPatch(t, `{"baz":"qux","foo":"bar"}`,
+		`[{"op":"remove","path":"/baz"}]`,
+		`{"foo":"bar"}`)
+	testPatch(t, `{"foo":["bar","qux","baz"]}`,
+		`[{"op":"remove","path":"/foo/1"}]`,
+		`{"foo":["bar","baz"]}`)
+	testPatch(t, `{"baz":"qux","foo":"bar"}`,
+		`[{"op":"replace","path":"/baz","value":"boo"}]`,
+		`{"baz":"boo","foo":"bar"}`)
+	testPatch(t, `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+		`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+		`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`)
+	testPatch(t, `{"foo":["all","grass","cows","eat"]}`,
+		`[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+		`{"foo":["all","cows","eat","grass"]}`)
+	testPatch(t, `{"baz":"qux","foo":["a",2,"c"]}`,
+		`[{"op":"test","path":"/baz","value":"qux"},`+
+			`{"op":"test","path":"/foo/1","value":2.0}]`,
+		`{"baz":"qux","foo":["a",2,"c"]}`)
+	testPatchError(t, `{"baz":"qux"}`,
+		`[{"op":"test","path":"/baz","value":"bar"}]`, ErrTestFailed)
+	testPatch(t, `{"foo":"bar"}`,
+		`[{"op":"add","path":"/child","value":{"grandchild":{}}}]`,
+		`{"foo":"bar","child":{"grandchild":{}}}`)
+	testPatchError(t, `{"foo":"bar"}`,
+		`[{"op":"add","path":"/baz/bat","value":"qux"}]`, ErrPathNotFound)
+	testPatch(t, `{"/":9,"~1":10}`,
+		`[{"op":"test","path":"/~01","value":10}]`,
+		`{"/":9,"~1":10}`)
+	testPatch(t, `{"foo":["bar"]}`,
+		`[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+		`{"foo":["bar",["abc","def"]]}`)
+	testPatch(t, `{"":1}`,
+		`[{"op":"replace","path":"/","value":2}]`, `{"":2}`)
+
+	// formatting of untouched parts is preserved
+	testPatch(t, "{\n  \"a\": 1.50,\n  \"b\": [ 1, 2 ],\n  \"c\": 3\n}",
+		`[{"op":"remove","path":"/b/0"},{"op":"remove","path":"/c"},`+
+			`{"op":"copy","from":"/a","path":"/d"}]`,
+		"{\n  \"a\": 1.50,\n  \"b\": [ 2 ],\"d\":1.50\n}")
+	testPatch(t, `{"a":[]}`,
+		`[{"op":"add","path":"/a/0","value":1},`+
+			`{"op":"add","path":"/a/1","value":2}]`,
+		`{"a":[1,2]}`)
+	testPatch(t, `{"a":{"b":1}}`,
+		`[{"op":"add","path":"/a/b","value":2}]`, `{"a":{"b":2}}`)
+	testPatch(t, `{"a":1}`,
+		`[{"op":"add","path":"","value":[true]}]`, `[true]`)
+
+	// atomic
+	testPatchError(t, `{"a":1}`,
+		`[{"op":"remove","path":"/a"},{"op":"remove","path":"/a"}]`,
+		ErrPathNotFound)
+	testPatchError(t, `{"a":[1]}`,
+		`[{"op":"add","path":"/a/2","value":1}]`, ErrPathNotFound)
+	testPatchError(t, `{"a":[1]}`,
+		`[{"op":"remove","path":"/a/01"}]`, ErrPathNotFound)
+	testPatchError(t, `{"a":{"b":1}}`,
+		`[{"op":"move","from":"/a","path":"/a/b/c"}]`, ErrInvalidPatch)
+	testPatchError(t, `{"a":1}`,
+		`[{"op":"jump","path":"/a"}]`, ErrInvalidPatch)
+	testPatchError(t, `{"a":1}`,
+		`[{"op":"add","path":"/b"}]`, ErrInvalidPatch)
+	testPatchError(t, `{"a":1}`, `{"op":"remove"}`, ErrInvalidPatch)
+	_, err := ApplyPatch(`{"a":1}`, `[{"op":"remove","path":"/b"}]`)
+	assert(t, err.Error() ==
+		`gjson: patch operation 0 (remove "/b"): path not found`)
+}
+
+func TestApplyDiffPatch(t *testing.T) {
+	pairs := [][2]string{
+		{`{"a":1,"b":[1,2,3],"c":{"d":true}}`,
+			`{"a":2,"b":[1],"c":{"e":null},"f":"x"}`},
+		{`[1,2]`, `[1,2,3,4]`},
+		{`{"a":[{"id":1,"v":1},{"id":2}]}`, `{"a":[{"id":3},{"id":1,"v":2}]}`},
+		{`{"a":1}`, `"replaced"`},
+	}
+	for _, pair := range pairs {
+		for _, opts := range []*DiffOptions{
+			nil, {IgnoreArrayOrder: true}, {ArrayKey: "id"},
+		} {
+			out, err := ApplyPatch(pair[0],
+				Patch(DiffWithOptions(pair[0], pair[1], opts)))
+			assert(t, err == nil)
+			assert(t, len(DiffWithOptions(out, pair[1], opts)) == 0)
+		}
+	}
+}
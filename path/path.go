@@ -0,0 +1,475 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package path builds GJSON paths.
+//
+// Each component is escaped for where it's written, so keys may have dots,
+// pipes or wildcards, and query values and modifier arguments are written as
+// json.
+//
+//	p := path.Key("a.b").Index(0).Query(path.Eq("last", `Mur"phy`)).
+//		Modifier("reverse", nil)
+//	gjson.Get(json, p.String()) // a\.b.0.#(last=="Mur\"phy").@reverse
+//
+// A path is built as a syntax tree, and its text is guaranteed to parse back
+// to the same tree. A path that can't be written that way, like one with a
+// query key that ends with a space, has an error instead.
+//
+// A prepared path has placeholders for arguments, which are bound as values
+// rather than read as path syntax.
+//
+//	path.Get(json, `friends.#(last==$1).first`, name)
+package path
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/gjson/syntax"
+)
+
+var (
+	// ErrIndex is returned for a negative index.
+	ErrIndex = errors.New("path: negative index")
+	// ErrModifierName is returned for a modifier name that can't be written
+	// in a path.
+	ErrModifierName = errors.New("path: invalid modifier name")
+	// ErrOperator is returned for an unknown query operator.
+	ErrOperator = errors.New("path: invalid query operator")
+	// ErrValue is returned for a value that isn't valid json, or that can't
+	// be encoded as json.
+	ErrValue = errors.New("path: invalid json value")
+	// ErrPath is returned when the text of a path doesn't parse back to the
+	// same path.
+	ErrPath = errors.New("path: cannot be written as a path")
+)
+
+// Path is a path that's being built. The zero value is an empty path. Each
+// method returns a new path, leaving the one it's called on as it was.
+//
+// Components are separated by a '.', unless Pipe is called before them.
+type Path struct {
+	lines bool
+	steps []syntax.Step
+	pipe  bool // the next component is separated by a '|'
+	err   error
+}
+
+// Lines returns a path that searches each line of JSON Lines as an array,
+// which is the ".." prefix.
+func Lines() Path {
+	return Path{lines: true}
+}
+
+// Key returns a path to a key.
+func Key(name string) Path { return Path{}.Key(name) }
+
+// KeyFold returns a path to a key that's matched without regard to case. See
+// Path.KeyFold.
+func KeyFold(name string) Path { return Path{}.KeyFold(name) }
+
+// Wildcard returns a path to the first key that matches a pattern, where '*'
+// matches any characters and '?' matches a single character.
+func Wildcard(pattern string) Path { return Path{}.Wildcard(pattern) }
+
+// Index returns a path to an array element.
+func Index(i int) Path { return Path{}.Index(i) }
+
+// Count returns a path to the number of elements of an array.
+func Count() Path { return Path{}.Count() }
+
+// Each returns a path that applies the components after it to each element
+// of an array.
+func Each() Path { return Path{}.Each() }
+
+// Descend returns a path to each member with a key at any depth. See
+// Path.Descend.
+func Descend(name string) Path { return Path{}.Descend(name) }
+
+// Query returns a path to the first element of an array that matches a
+// condition.
+func Query(c Cond) Path { return Path{}.Query(c) }
+
+// QueryAll returns a path to all elements of an array that match a
+// condition.
+func QueryAll(c Cond) Path { return Path{}.QueryAll(c) }
+
+// Modifier returns a path that starts with a modifier. See Path.Modifier.
+func Modifier(name string, arg interface{}) Path {
+	return Path{}.Modifier(name, arg)
+}
+
+// Literal returns a path to a json value. See Path.Literal.
+func Literal(value interface{}) Path { return Path{}.Literal(value) }
+
+// Array returns a path to an array of the results of other paths.
+func Array(paths ...Path) Path { return Path{}.Array(paths...) }
+
+// Object returns a path to an object of the results of other paths.
+func Object(fields ...Field) Path { return Path{}.Object(fields...) }
+
+// Key adds a key.
+func (p Path) Key(name string) Path {
+	return p.add(&syntax.Key{Name: name})
+}
+
+// KeyFold adds a key that's matched without regard to case. It's written with
+// the '~' prefix, so the text of the path needs an engine or options with
+// CaseInsensitivePrefix.
+func (p Path) KeyFold(name string) Path {
+	return p.add(&syntax.Key{Name: name, Fold: true})
+}
+
+// Wildcard adds a key that matches a pattern, where '*' matches any
+// characters and '?' matches a single character.
+func (p Path) Wildcard(pattern string) Path {
+	return p.add(&syntax.Key{Name: pattern,
+		Wild: strings.ContainsAny(pattern, "*?")})
+}
+
+// Index adds an array index.
+func (p Path) Index(i int) Path {
+	if i < 0 {
+		return p.fail(ErrIndex)
+	}
+	return p.add(&syntax.Index{N: i})
+}
+
+// Count adds a '#', which is the number of elements of an array when it's
+// the last component.
+func (p Path) Count() Path {
+	return p.add(&syntax.Hash{})
+}
+
+// Each adds a '#', which applies the components after it, up to the next
+// pipe, to each element of an array.
+func (p Path) Each() Path {
+	return p.add(&syntax.Hash{})
+}
+
+// Descend adds a recursive descent, such as "..name", which is an array of
+// each member with the key at any depth. The components after it, up to the
+// next pipe, apply to each member.
+func (p Path) Descend(name string) Path {
+	return p.add(&syntax.Descent{Key: &syntax.Key{Name: name}})
+}
+
+// Query adds a query for the first element of an array that matches a
+// condition.
+func (p Path) Query(c Cond) Path {
+	return p.query(c, false)
+}
+
+// QueryAll adds a query for all elements of an array that match a condition.
+func (p Path) QueryAll(c Cond) Path {
+	return p.query(c, true)
+}
+
+func (p Path) query(c Cond, all bool) Path {
+	if c.err != nil {
+		return p.fail(c.err)
+	}
+	return p.add(&syntax.Query{Path: c.path, Op: c.op, Value: c.value,
+		All: all})
+}
+
+// Modifier adds a modifier with an optional argument. A nil arg is no
+// argument, a string is written as is, and any other value is written as
+// json, where a json.RawMessage must be valid json.
+//
+// A string argument runs up to the next '|', so the component after it is
+// always separated by a pipe.
+func (p Path) Modifier(name string, arg interface{}) Path {
+	if name == "" || strings.ContainsAny(name, ".|:@#*?!\\[]{}()\"") {
+		return p.fail(ErrModifierName)
+	}
+	m := &syntax.Modifier{Name: name}
+	switch arg := arg.(type) {
+	case nil:
+	case string:
+		m.Arg, m.HasArg = arg, true
+	default:
+		value, err := encode(arg)
+		if err != nil {
+			return p.fail(err)
+		}
+		m.Arg, m.HasArg = value, true
+	}
+	p = p.add(m)
+	if _, ok := arg.(string); ok {
+		p.pipe = true
+	}
+	return p
+}
+
+// Literal adds a json value, which is encoded like json.Marshal, unless it's
+// a json.RawMessage. A literal after other components is always separated by
+// a pipe. A string or a number literal runs to the end of the path, so it
+// must be the last component.
+func (p Path) Literal(value interface{}) Path {
+	text, err := encode(value)
+	if err != nil {
+		return p.fail(err)
+	}
+	return p.add(&syntax.Literal{Value: text})
+}
+
+// Field is a member of an object built by Object.
+type Field struct {
+	// Name is the name of the member. An empty name is the name of the last
+	// key of the path, as with gjson.
+	Name string
+	// Path is the path to the value of the member.
+	Path Path
+}
+
+// Array adds an array of the results of other paths.
+func (p Path) Array(paths ...Path) Path {
+	fields := make([]Field, len(paths))
+	for i, sel := range paths {
+		fields[i].Path = sel
+	}
+	return p.multipath(fields, false)
+}
+
+// Object adds an object of the results of other paths.
+func (p Path) Object(fields ...Field) Path {
+	return p.multipath(fields, true)
+}
+
+func (p Path) multipath(fields []Field, object bool) Path {
+	m := &syntax.Multipath{Object: object}
+	for _, f := range fields {
+		if f.Path.err != nil {
+			return p.fail(f.Path.err)
+		}
+		sel := &syntax.Selector{Path: f.Path.tree()}
+		name := f.Name
+		if object && name == "" && len(f.Path.steps) > 0 {
+			// gjson names a member with the text of the last key, so an
+			// escaped key is named explicitly
+			last := f.Path.steps[len(f.Path.steps)-1].Component
+			if k, ok := last.(*syntax.Key); ok && !k.Fold && !k.Wild &&
+				syntax.Format(&syntax.Path{Steps: []syntax.Step{
+					{Component: k}}}) != k.Name {
+				name = k.Name
+			}
+		}
+		if object && name != "" {
+			sel.Name = string(gjson.AppendJSONString(nil, name))
+		}
+		m.Selectors = append(m.Selectors, sel)
+	}
+	return p.add(m)
+}
+
+// Pipe makes the next component separated by a '|' rather than a '.', which
+// ends the components that apply to each element after a '#', and applies
+// the next component to the result so far.
+func (p Path) Pipe() Path {
+	if len(p.steps) > 0 {
+		p.pipe = true
+	}
+	return p
+}
+
+// Then adds the components of another path, separated by a '.', or by a '|'
+// when Pipe was called. The other path can't be a JSON Lines path.
+func (p Path) Then(next Path) Path {
+	if next.err != nil {
+		return p.fail(next.err)
+	}
+	if next.lines {
+		return p.fail(ErrPath)
+	}
+	for i, step := range next.steps {
+		if i > 0 {
+			p.pipe = step.Sep == syntax.Pipe
+		}
+		p = p.add(step.Component)
+	}
+	if len(next.steps) > 0 {
+		p.pipe = next.pipe
+	}
+	return p
+}
+
+func (p Path) add(c syntax.Component) Path {
+	if p.err != nil {
+		return p
+	}
+	sep := syntax.Dot
+	if len(p.steps) == 0 {
+		sep = syntax.NoSep
+	} else if _, ok := c.(*syntax.Literal); ok || p.pipe {
+		sep = syntax.Pipe
+	}
+	steps := make([]syntax.Step, len(p.steps), len(p.steps)+1)
+	copy(steps, p.steps)
+	p.steps = append(steps, syntax.Step{Sep: sep, Component: c})
+	p.pipe = false
+	return p
+}
+
+func (p Path) fail(err error) Path {
+	if p.err == nil {
+		p.err = err
+	}
+	return p
+}
+
+func (p Path) tree() *syntax.Path {
+	return &syntax.Path{Lines: p.lines, Steps: p.steps}
+}
+
+// Tree returns the syntax tree of the path.
+func (p Path) Tree() *syntax.Path {
+	t := p.tree()
+	t.Steps = append([]syntax.Step(nil), t.Steps...)
+	return t
+}
+
+// Err returns the first error from building the path, or ErrPath when its
+// text doesn't parse back to the same tree.
+func (p Path) Err() error {
+	_, err := p.text()
+	return err
+}
+
+// String returns the text of the path, or an empty string when it has an
+// error.
+func (p Path) String() string {
+	text, _ := p.text()
+	return text
+}
+
+func (p Path) text() (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	if len(p.steps) == 0 && !p.lines {
+		return "", nil
+	}
+	text := syntax.Format(p.tree())
+	t, err := syntax.ParseMode(text, syntax.CaseInsensitivePrefix)
+	if err != nil || !syntax.Equal(t, p.tree()) {
+		return "", fmt.Errorf("%w: %q", ErrPath, text)
+	}
+	return text, nil
+}
+
+// Cond is a query condition.
+type Cond struct {
+	path  *syntax.Path
+	op    string
+	value string
+	err   error
+}
+
+// Where returns a condition that compares the result of a path on each
+// element with a value, which is encoded like json.Marshal, unless it's a
+// json.RawMessage. The operator is one of "==", "=", "!=", "<", "<=", ">",
+// ">=", "%" (like) and "!%" (not like), or "" for when the path exists, in
+// which case the value is ignored. An empty path is the element itself.
+func Where(p Path, op string, value interface{}) Cond {
+	var c Cond
+	if p.err != nil {
+		c.err = p.err
+		return c
+	}
+	if len(p.steps) > 0 {
+		c.path = p.tree()
+	}
+	switch op {
+	case "":
+		return c
+	case "=":
+		op = "=="
+	case "==", "!=", "<", "<=", ">", ">=", "%", "!%":
+	default:
+		c.err = ErrOperator
+		return c
+	}
+	c.op = op
+	c.value, c.err = encode(value)
+	return c
+}
+
+// key returns the path to a key, or the empty path for an empty key.
+func key(name string) Path {
+	if name == "" {
+		return Path{}
+	}
+	return Key(name)
+}
+
+// Has returns a condition for elements that have a key. An empty key is
+// the element itself.
+func Has(name string) Cond { return Where(key(name), "", nil) }
+
+// Eq returns a condition for elements with a key equal to a value. An empty
+// key is the element itself.
+func Eq(name string, value interface{}) Cond {
+	return Where(key(name), "==", value)
+}
+
+// Ne returns a condition for elements with a key not equal to a value.
+func Ne(name string, value interface{}) Cond {
+	return Where(key(name), "!=", value)
+}
+
+// Lt returns a condition for elements with a key less than a value.
+func Lt(name string, value interface{}) Cond {
+	return Where(key(name), "<", value)
+}
+
+// Le returns a condition for elements with a key less than or equal to a
+// value.
+func Le(name string, value interface{}) Cond {
+	return Where(key(name), "<=", value)
+}
+
+// Gt returns a condition for elements with a key greater than a value.
+func Gt(name string, value interface{}) Cond {
+	return Where(key(name), ">", value)
+}
+
+// Ge returns a condition for elements with a key greater than or equal to a
+// value.
+func Ge(name string, value interface{}) Cond {
+	return Where(key(name), ">=", value)
+}
+
+// Like returns a condition for elements with a key that matches a pattern,
+// where '*' matches any characters and '?' matches a single character.
+func Like(name, pattern string) Cond {
+	return Where(key(name), "%", pattern)
+}
+
+// NotLike returns a condition for elements with a key that doesn't match a
+// pattern.
+func NotLike(name, pattern string) Cond {
+	return Where(key(name), "!%", pattern)
+}
+
+// encode returns the json of a value.
+func encode(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return string(gjson.AppendJSONString(nil, v)), nil
+	case json.RawMessage:
+		if !gjson.Valid(string(v)) {
+			return "", ErrValue
+		}
+		return string(v), nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrValue, err)
+	}
+	return string(b), nil
+}
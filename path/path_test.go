@@ -0,0 +1,147 @@
+package path
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/gjson/syntax"
+)
+
+func assert(t testing.TB, cond bool) {
+	t.Helper()
+	if !cond {
+		t.Fatal("assert failed")
+	}
+}
+
+const testJSON = `{
+  "a.b": [{"friends": [
+    {"first": "Dale", "last": "Mur\"phy", "age": 44},
+    {"first": "Roger", "last": "Craig", "age": 68},
+    {"first": "Jane", "last": "Mur\"phy", "age": 47}
+  ]}],
+  "x|y": {"*": "star", "k:v": "colon", "0": "zero"},
+  "Name": "Tom",
+  "lines": ["a|b", "c"]
+}`
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		p    Path
+		text string
+		res  string
+	}{
+		{Key("a.b").Index(0).Key("friends").
+			Query(Eq("last", `Mur"phy`)).Key("first"),
+			`a\.b.0.friends.#(last=="Mur\"phy").first`, `"Dale"`},
+		{Key("a.b").Index(0).Key("friends").
+			QueryAll(Eq("last", `Mur"phy`)).Key("first").Pipe().
+			Modifier("reverse", nil),
+			`a\.b.0.friends.#(last=="Mur\"phy")#.first|@reverse`,
+			`["Jane","Dale"]`},
+		{Key("a.b").Index(0).Key("friends").QueryAll(Gt("age", 45)).
+			Key("first"), `a\.b.0.friends.#(age>45)#.first`,
+			`["Roger","Jane"]`},
+		{Key("a.b").Index(0).Key("friends").Each().Key("age").Pipe().Count(),
+			`a\.b.0.friends.#.age|#`, `3`},
+		{Key("x|y").Key("*"), `x\|y.\*`, `"star"`},
+		{Key("x|y").Key("k:v"), `x\|y.k\:v`, `"colon"`},
+		{Key("x|y").Key("0"), `x\|y.\0`, `"zero"`},
+		{KeyFold("name"), `~name`, `"Tom"`},
+		{Wildcard("N*e"), `N*e`, `"Tom"`},
+		{Key("lines").Query(Eq("", "a|b")), `lines.#(=="a|b")`, `"a|b"`},
+		{Key("lines").Query(Like("", "c*")), `lines.#(%"c*")`, `"c"`},
+		{Key("lines").Modifier("join", map[string]bool{"preserve": true}),
+			`lines.@join:{"preserve":true}`, ``},
+		{Key("Name").Modifier("case", "upper").Key("x"),
+			`Name.@case:upper|x`, ``},
+		{Key("Name").Literal(true), `Name|!true`, `true`},
+		{Literal("a.b"), `!"a.b"`, `"a.b"`},
+		{Array(Key("Name"), Key("x|y").Key("*")), `[Name,x\|y.\*]`,
+			`["Tom","star"]`},
+		{Object(Field{Name: "a,b", Path: Key("Name")},
+			Field{Path: Key("x|y").Key("0")}),
+			`{"a,b":Name,"0":x\|y.\0}`, `{"a,b":"Tom","0":"zero"}`},
+		{Key("a.b").Index(0).Key("friends").
+			Query(Where(Key("first").Modifier("reverse", nil), "=", "x")),
+			`a\.b.0.friends.#(first.@reverse=="x")`, ``},
+		{Key("lines").Query(Has("")), `lines.#()`, ``},
+		{Lines().Index(0), `..0`, ``},
+		{Descend("first"), `..first`, `["Dale","Roger","Jane"]`},
+		{Descend("x|y").Key("0"), `..x\|y.\0`, `["zero"]`},
+		{Key("a.b").Descend("friends").QueryAll(Gt("age", 45)).Key("first"),
+			`a\.b..friends.#(age>45)#.first`, `["Roger","Jane"]`},
+		{Descend("0"), `..\0`, `["zero"]`},
+		{Descend("0a"), `..\0a`, `[]`},
+		{Path{}, ``, ``},
+	}
+	for _, tt := range tests {
+		if err := tt.p.Err(); err != nil {
+			t.Fatalf("%q: %v", tt.text, err)
+		}
+		text := tt.p.String()
+		if text != tt.text {
+			t.Fatalf("expected %q, got %q", tt.text, text)
+		}
+		if text != "" {
+			tree, err := syntax.ParseMode(text, syntax.CaseInsensitivePrefix)
+			assert(t, err == nil && syntax.Equal(tree, tt.p.Tree()))
+		}
+		if tt.res != "" {
+			res, _ := gjson.GetWithOptions(testJSON, text,
+				&gjson.Options{CaseInsensitivePrefix: true})
+			if res.Raw != tt.res {
+				t.Fatalf("%q: expected %q, got %q", text, tt.res, res.Raw)
+			}
+		}
+	}
+}
+
+func TestPathImmutable(t *testing.T) {
+	base := Key("a")
+	p1 := base.Key("b")
+	p2 := base.Key("c")
+	assert(t, base.String() == "a")
+	assert(t, p1.String() == "a.b" && p2.String() == "a.c")
+	piped := base.Pipe()
+	assert(t, piped.Key("b").String() == "a|b")
+	assert(t, base.Key("b").String() == "a.b")
+	assert(t, Key("a").Then(Key("b").Pipe().Key("c")).String() == "a.b|c")
+	assert(t, Key("a").Pipe().Then(Index(0)).String() == "a|0")
+	tree := p1.Tree()
+	tree.Steps[0].Sep = syntax.Pipe
+	assert(t, p1.String() == "a.b")
+}
+
+func TestPathErrors(t *testing.T) {
+	tests := []struct {
+		p   Path
+		err error
+	}{
+		{Index(-1), ErrIndex},
+		{Key("a").Modifier("a.b", nil), ErrModifierName},
+		{Key("a").Modifier("", nil), ErrModifierName},
+		{Query(Where(Key("a"), "~", 1)), ErrOperator},
+		{Query(Eq("a", func() {})), ErrValue},
+		{Literal(json.RawMessage(`{"a"`)), ErrValue},
+		{Modifier("x", json.RawMessage(`[1`)), ErrValue},
+		{Literal("a").Key("b"), ErrPath},
+		{Modifier("x", "a|b"), ErrPath},
+		{Query(Eq("a ", 1)), ErrPath},
+		{Key("a").Then(Lines()), ErrPath},
+		{Array(Index(-1)), ErrIndex},
+		{Index(-1).Key("a"), ErrIndex},
+		{Descend(""), ErrPath},
+		{Key("a").Key("").Key("b"), ErrPath},
+		{Lines().Descend("a"), ErrPath},
+	}
+	for i, tt := range tests {
+		err := tt.p.Err()
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("%d: expected %v, got %v", i, tt.err, err)
+		}
+		assert(t, tt.p.String() == "")
+	}
+}
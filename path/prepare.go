@@ -0,0 +1,231 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package path
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/gjson/syntax"
+)
+
+// ErrArgs is returned when binding a prepared path with an argument missing
+// for a placeholder, or with an argument that no placeholder uses.
+var ErrArgs = errors.New("path: wrong number of arguments")
+
+// Prepared is a path with placeholders, which are "$1", "$2" and so on, for
+// arguments that are bound later. A placeholder can be a query value, a
+// key, or a modifier argument.
+//
+//	p, err := path.Prepare(`friends.#(last==$1).first`)
+//	res := p.Get(json, `Murphy")|@dig:secret`) // no friend has that name
+//
+// Arguments are bound into the syntax tree of the path, and are never read
+// as path syntax, so an argument can't change the shape of the path.
+type Prepared struct {
+	text string
+	tree *syntax.Path
+}
+
+// Prepare parses a path with placeholders.
+func Prepare(text string) (*Prepared, error) {
+	tree, err := syntax.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Prepared{text: text, tree: tree}, nil
+}
+
+// MustPrepare is like Prepare but panics if the path can't be parsed.
+func MustPrepare(text string) *Prepared {
+	p, err := Prepare(text)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the text of the prepared path.
+func (p *Prepared) String() string {
+	return p.text
+}
+
+// Bind returns the path with its placeholders replaced by arguments, where
+// "$1" is the first argument. A query value is encoded like json.Marshal,
+// unless it's a json.RawMessage. A key is a string, or an int for an array
+// index, and the key of a recursive descent, as in "..$1", is a string. A
+// modifier argument is like the arg of Path.Modifier.
+func (p *Prepared) Bind(args ...interface{}) Path {
+	b := binder{src: p.text, args: args, used: make([]bool, len(args))}
+	tree, err := b.path(p.tree)
+	if err == nil {
+		for _, used := range b.used {
+			if !used {
+				err = ErrArgs
+			}
+		}
+	}
+	if err != nil {
+		return Path{err: err}
+	}
+	return Path{lines: tree.Lines, steps: tree.Steps}
+}
+
+// Get searches json for the path with its placeholders bound to arguments.
+// An empty result is returned when the arguments can't be bound.
+func (p *Prepared) Get(json string, args ...interface{}) gjson.Result {
+	res, _ := p.GetE(json, args...)
+	return res
+}
+
+// GetE is like Get but returns an error when the arguments can't be bound.
+func (p *Prepared) GetE(json string, args ...interface{}) (gjson.Result,
+	error,
+) {
+	bound := p.Bind(args...)
+	if err := bound.Err(); err != nil {
+		return gjson.Result{}, err
+	}
+	// the bound tree is searched as it is, as its text could be read
+	// differently by gjson, such as a '|' in a query value after a '#'
+	return syntax.Eval(json, bound.tree()), nil
+}
+
+// Get searches json for a path with placeholders bound to arguments. See
+// Prepared.Bind.
+//
+//	path.Get(json, `friends.#(last==$1).first`, name)
+func Get(json, path string, args ...interface{}) gjson.Result {
+	res, _ := GetE(json, path, args...)
+	return res
+}
+
+// GetE is like Get but returns an error when the path can't be parsed or
+// the arguments can't be bound.
+func GetE(json, path string, args ...interface{}) (gjson.Result, error) {
+	p, err := Prepare(path)
+	if err != nil {
+		return gjson.Result{}, err
+	}
+	return p.GetE(json, args...)
+}
+
+type binder struct {
+	src  string
+	args []interface{}
+	used []bool
+}
+
+// arg returns the argument of a placeholder, and false when the text isn't a
+// placeholder.
+func (b *binder) arg(text string) (interface{}, bool, error) {
+	if len(text) < 2 || text[0] != '$' || text[1] == '0' {
+		return nil, false, nil
+	}
+	n, err := strconv.Atoi(text[1:])
+	if err != nil || text[1] == '+' || text[1] == '-' {
+		return nil, false, nil
+	}
+	if n > len(b.args) {
+		return nil, false, ErrArgs
+	}
+	b.used[n-1] = true
+	return b.args[n-1], true, nil
+}
+
+// path returns a copy of the tree with its placeholders bound.
+func (b *binder) path(p *syntax.Path) (*syntax.Path, error) {
+	if p == nil {
+		return nil, nil
+	}
+	bound := &syntax.Path{Lines: p.Lines, Steps: make([]syntax.Step,
+		len(p.Steps))}
+	for i, step := range p.Steps {
+		c, err := b.component(step.Component)
+		if err != nil {
+			return nil, err
+		}
+		bound.Steps[i] = syntax.Step{Sep: step.Sep, Component: c}
+	}
+	return bound, nil
+}
+
+func (b *binder) component(comp syntax.Component) (syntax.Component,
+	error,
+) {
+	switch c := comp.(type) {
+	case *syntax.Key:
+		if b.src[c.Start:c.End] != c.Name {
+			// an escaped key isn't a placeholder
+			return c, nil
+		}
+		arg, ok, err := b.arg(c.Name)
+		if !ok {
+			return c, err
+		}
+		switch arg := arg.(type) {
+		case string:
+			return &syntax.Key{Name: arg}, nil
+		case int:
+			if arg < 0 {
+				return nil, ErrIndex
+			}
+			return &syntax.Index{N: arg}, nil
+		}
+		return nil, ErrValue
+	case *syntax.Descent:
+		key := c.Key
+		if b.src[key.Start:key.End] != key.Name {
+			return c, nil
+		}
+		arg, ok, err := b.arg(key.Name)
+		if !ok {
+			return c, err
+		}
+		if name, ok := arg.(string); ok {
+			return &syntax.Descent{Key: &syntax.Key{Name: name}}, nil
+		}
+		return nil, ErrValue
+	case *syntax.Query:
+		q := *c
+		var err error
+		if q.Path, err = b.path(c.Path); err != nil {
+			return nil, err
+		}
+		arg, ok, err := b.arg(c.Value)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			if q.Value, err = encode(arg); err != nil {
+				return nil, err
+			}
+		}
+		return &q, nil
+	case *syntax.Modifier:
+		arg, ok, err := b.arg(c.Arg)
+		if !ok || !c.HasArg {
+			return c, err
+		}
+		m := Modifier(c.Name, arg)
+		if m.err != nil {
+			return nil, m.err
+		}
+		return m.steps[0].Component, nil
+	case *syntax.Multipath:
+		m := *c
+		m.Selectors = make([]*syntax.Selector, len(c.Selectors))
+		for i, sel := range c.Selectors {
+			path, err := b.path(sel.Path)
+			if err != nil {
+				return nil, err
+			}
+			m.Selectors[i] = &syntax.Selector{Name: sel.Name, Path: path}
+		}
+		return &m, nil
+	}
+	return comp, nil
+}
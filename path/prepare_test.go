@@ -0,0 +1,163 @@
+package path
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestPrepare(t *testing.T) {
+	const friends = `a\.b.0.friends`
+	tests := []struct {
+		path string
+		args []interface{}
+		text string
+		res  string
+	}{
+		{friends + `.#(last==$1).first`, []interface{}{`Mur"phy`},
+			friends + `.#(last=="Mur\"phy").first`, `"Dale"`},
+		{friends + `.#(age>$1)#.first|@reverse`, []interface{}{45},
+			friends + `.#(age>45)#.first|@reverse`, `["Jane","Roger"]`},
+		{friends + `.#(last==$2)#.$1`, []interface{}{"age", `Mur"phy`},
+			friends + `.#(last=="Mur\"phy")#.age`, `[44,47]`},
+		{friends + `.$1.first`, []interface{}{1}, friends + `.1.first`,
+			`"Roger"`},
+		{`$1.$2`, []interface{}{"x|y", "*"}, `x\|y.\*`, `"star"`},
+		{`{a:$1,b:Name}`, []interface{}{"x|y"}, `{a:x\|y,b:Name}`,
+			`{"a":{"*": "star", "k:v": "colon", "0": "zero"},"b":"Tom"}`},
+		{`lines|@join:$1`, []interface{}{json.RawMessage(`{}`)},
+			`lines|@join:{}`, `{}`},
+		{`a\.b|@dig:$1`, []interface{}{"age"}, `a\.b|@dig:age`,
+			`[44,68,47]`},
+		{friends + `.#(last==$1).first`, []interface{}{`")|@dig:first`},
+			friends + `.#(last=="\")|@dig:first").first`, ``},
+		{`..$1.#(age>$2)#.first`, []interface{}{"friends", 45},
+			`..friends.#(age>45)#.first`, `["Roger","Jane"]`},
+		{`\$1.~$1`, nil, `\$1.\~\$1`, ``},
+		{`$0.$01`, nil, `\$0.\$01`, ``},
+		{`[lines].0.#(==$1)`, []interface{}{"a|b"}, `[lines].0.#(=="a|b")`,
+			`"a|b"`},
+		// like gjson, the path after "#." is split at the '|' of the value
+		{`[lines].#.#(==$1)`, []interface{}{"a|b"}, `[lines].#.#(=="a|b")`,
+			``},
+	}
+	for _, tt := range tests {
+		p := MustPrepare(tt.path)
+		assert(t, p.String() == tt.path)
+		bound := p.Bind(tt.args...)
+		if err := bound.Err(); err != nil {
+			t.Fatalf("%q: %v", tt.path, err)
+		}
+		if text := bound.String(); text != tt.text {
+			t.Fatalf("%q: expected %q, got %q", tt.path, tt.text, text)
+		}
+		res, err := GetE(testJSON, tt.path, tt.args...)
+		if err != nil || res.Raw != tt.res {
+			t.Fatalf("%q: expected %q, got %q, %v", tt.path, tt.res, res.Raw,
+				err)
+		}
+		assert(t, Get(testJSON, tt.path, tt.args...).Raw == tt.res)
+		assert(t, p.Get(testJSON, tt.args...).Raw == tt.res)
+	}
+}
+
+func TestPrepareGet(t *testing.T) {
+	// a path without placeholders is searched like gjson.Get
+	paths := []string{
+		`a\.b.0.friends.#(age>45)#.first`,
+		`a\.b.0.friends.#.first|@reverse`,
+		`Name|@this.!"x"`,
+		`Name.@reverse.!true`,
+		`..first`,
+		`..first.!true`,
+		`..friends.#.age`,
+		`{Name,x\|y}.!1`,
+		`[Name].!1`,
+		`lines.#.!1`,
+		`missing.[0]`,
+	}
+	for _, path := range paths {
+		exp := gjson.Get(testJSON, path)
+		res, err := GetE(testJSON, path)
+		if err != nil || res.Raw != exp.Raw || res.Type != exp.Type {
+			t.Fatalf("%q: expected %q, got %q, %v", path, exp.Raw, res.Raw,
+				err)
+		}
+		assert(t, MustPrepare(path).Get(testJSON).Raw == exp.Raw)
+	}
+}
+
+// genParts are put together into random paths with placeholders.
+var genParts = []string{"a\\.b", "0", "friends", "first", "age", "lines",
+	"Name", "missing", "$1", "#", "#(age>$1)#", "#(age>$1)", "#(first==$2)#",
+	"#(first==$2)", "#(age>99)#", "@reverse", "@this", "@flatten", "@keys",
+	"!true", `!"s"`, "[first,$2]", "{age,first}", "{$1|0}", "..first"}
+
+func TestPrepareGenerated(t *testing.T) {
+	// the results of prepared paths are the same as gjson.Get of the text of
+	// the bound paths
+	r := rand.New(rand.NewSource(1))
+	args := [][]interface{}{{45, "Jane"}, {99, "Dale"}, {"friends", "first"},
+		{0, json.RawMessage(`"x"`)}}
+	for i := 0; i < 5000; i++ {
+		var sb strings.Builder
+		for j, n := 0, 1+r.Intn(6); j < n; j++ {
+			if j > 0 {
+				sb.WriteString([]string{".", ".", "|"}[r.Intn(3)])
+			}
+			sb.WriteString(genParts[r.Intn(len(genParts))])
+		}
+		p, err := Prepare(sb.String())
+		if err != nil {
+			continue
+		}
+		for _, args := range args {
+			bound := p.Bind(args...)
+			if bound.Err() != nil {
+				continue
+			}
+			exp := gjson.Get(testJSON, bound.String())
+			res, err := p.GetE(testJSON, args...)
+			if err != nil || res.Raw != exp.Raw || res.Type != exp.Type {
+				t.Fatalf("%q: %v: expected %q, got %q, %v", p, args, exp.Raw,
+					res.Raw, err)
+			}
+		}
+	}
+	res := MustPrepare(`a\.b.0.friends.#(age>$1)#.first|0`).Get(testJSON, 99)
+	assert(t, res.Raw == "[]")
+}
+
+func TestPrepareErrors(t *testing.T) {
+	tests := []struct {
+		path string
+		args []interface{}
+		err  error
+	}{
+		{`a.#(b==$1)`, nil, ErrArgs},
+		{`a.#(b==$2)`, []interface{}{1}, ErrArgs},
+		{`a.#(b==$1)`, []interface{}{1, 2}, ErrArgs},
+		{`a.$1`, []interface{}{1.5}, ErrValue},
+		{`a.$1`, []interface{}{-1}, ErrIndex},
+		{`..$1`, []interface{}{0}, ErrValue},
+		{`a.#(b==$1)`, []interface{}{func() {}}, ErrValue},
+		{`a|@dig:$1`, []interface{}{"age|@this"}, ErrPath},
+	}
+	for _, tt := range tests {
+		_, err := GetE(testJSON, tt.path, tt.args...)
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("%q: expected %v, got %v", tt.path, tt.err, err)
+		}
+		assert(t, !MustPrepare(tt.path).Get(testJSON, tt.args...).Exists())
+	}
+	_, err := Prepare("a.#(")
+	assert(t, err != nil)
+	_, err = GetE(testJSON, "a.#(")
+	assert(t, err != nil)
+	defer func() { assert(t, recover() != nil) }()
+	MustPrepare("a.#(")
+}
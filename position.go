@@ -0,0 +1,67 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gjson
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Position is a location in json. Line and Column start at 1, and Column
+// counts bytes, like go/token. The zero Position is not valid.
+type Position struct {
+	// Offset is the byte offset, starting at 0.
+	Offset int
+	Line   int
+	Column int
+}
+
+// IsValid reports whether the position is known.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String returns the position as "line:column", or "-" when it's not valid.
+func (p Position) String() string {
+	if !p.IsValid() {
+		return "-"
+	}
+	return strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Column)
+}
+
+// Locate returns the position of a byte offset in json. An offset past the
+// end of the json is invalid.
+//
+//	gjson.Locate("{\n  \"a\": 1\n}", 9) // 2:8
+func Locate(json string, offset int) Position {
+	if offset < 0 || offset > len(json) {
+		return Position{}
+	}
+	line := strings.Count(json[:offset], "\n")
+	col := offset - strings.LastIndexByte(json[:offset], '\n')
+	return Position{Offset: offset, Line: line + 1, Column: col}
+}
+
+// Span returns the start and end positions of the result in json, which must
+// be the json that the result came from. The end is just past the last byte
+// of the value. Both positions are invalid when the result was computed, such
+// as by a modifier or a multipath.
+//
+// The elements of a result from a path with '#' or a query have their own
+// spans, from Indexes.
+//
+//	res := gjson.Get(json, "friends.#(age>45)#")
+//	res.ForEach(func(_, friend gjson.Result) bool {
+//		start, end := friend.Span(json)
+//		...
+//	})
+func (t Result) Span(json string) (start, end Position) {
+	if !rawAt(json, t.Index, t.Raw) {
+		return Position{}, Position{}
+	}
+	start = Locate(json, t.Index)
+	end = Locate(json, t.Index+len(t.Raw))
+	return start, end
+}
@@ -0,0 +1,81 @@
+package gjson
+
+import "testing"
+
+const positionJSON = `{
+  "name": "Tom",
+  "friends": [
+    {"first": "Dale", "age": 44, "nets": ["ig", "fb"]},
+    {"first": "Roger", "age": 68, "nets": ["fb"]},
+    {"first": "Jane", "age": 47, "nets": []}
+  ]
+}`
+
+func TestLocate(t *testing.T) {
+	json := "{\n  \"a\": 1\n}"
+	assert(t, Locate(json, 0) == Position{Offset: 0, Line: 1, Column: 1})
+	assert(t, Locate(json, 9) == Position{Offset: 9, Line: 2, Column: 8})
+	assert(t, Locate(json, 2) == Position{Offset: 2, Line: 2, Column: 1})
+	assert(t, Locate(json, len(json)).String() == "3:2")
+	assert(t, !Locate(json, len(json)+1).IsValid())
+	assert(t, !Locate(json, -1).IsValid())
+	assert(t, Position{}.String() == "-")
+}
+
+func TestSpan(t *testing.T) {
+	start, end := Get(positionJSON, "name").Span(positionJSON)
+	assert(t, start.String() == "2:11" && end.String() == "2:16")
+	assert(t, positionJSON[start.Offset:end.Offset] == `"Tom"`)
+
+	start, end = Get(positionJSON, "friends.1.age").Span(positionJSON)
+	assert(t, start.String() == "5:31" && end.Offset-start.Offset == 2)
+
+	// after a pipe
+	start, _ = Get(positionJSON, "friends|1|age").Span(positionJSON)
+	assert(t, start.String() == "5:31")
+
+	// computed results have no span
+	for _, path := range []string{"friends.#", "friends|@reverse",
+		"[name]", "friends.@reverse.#", "!true"} {
+		start, end = Get(positionJSON, path).Span(positionJSON)
+		assert(t, !start.IsValid() && !end.IsValid())
+	}
+
+	// each element has its own span
+	tests := []struct {
+		path  string
+		spans []string
+	}{
+		{"friends.#(age>45)#", []string{"5:5", "6:5"}},
+		{"friends.#(age>45)#.first", []string{"5:15", "6:15"}},
+		{"friends.#.age", []string{"4:30", "5:31", "6:30"}},
+		{"friends|#.age", []string{"4:30", "5:31", "6:30"}},
+		{"friends.#.nets.0", []string{"4:43", "5:44"}},
+		{"friends.#.nets.#", []string{"-", "-", "-"}},
+		{"friends.#.@reverse", []string{"-", "-", "-"}},
+		{"friends.#(age>45)#.nets.#", []string{"-", "-"}},
+	}
+	for _, tt := range tests {
+		var spans []string
+		Get(positionJSON, tt.path).ForEach(func(_, value Result) bool {
+			start, _ := value.Span(positionJSON)
+			spans = append(spans, start.String())
+			return true
+		})
+		if len(spans) != len(tt.spans) {
+			t.Fatalf("%q: expected %v, got %v", tt.path, tt.spans, spans)
+		}
+		for i := range spans {
+			if spans[i] != tt.spans[i] {
+				t.Fatalf("%q: expected %v, got %v", tt.path, tt.spans, spans)
+			}
+		}
+	}
+
+	// spans of a result from a result
+	friends := Get(positionJSON, "friends")
+	start, _ = friends.Get("#.first").Array()[2].Span(positionJSON)
+	assert(t, start.String() == "6:15")
+	start, _ = friends.Get("#.nets.#").Array()[0].Span(positionJSON)
+	assert(t, !start.IsValid())
+}
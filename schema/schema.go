@@ -0,0 +1,1015 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package schema validates json against a JSON Schema.
+//
+// Schemas follow draft 2020-12, covering the core, applicator, and validation
+// vocabularies. Only local references, such as "#/$defs/name" or "#anchor",
+// are supported. Dynamic references, which are "$dynamicRef" and
+// "$dynamicAnchor", aren't supported, and a schema with them fails to
+// compile. Annotation keywords, such as "format" and "title", are ignored.
+//
+// Values are validated in place using gjson.Result.ForEach, so a document is
+// never decoded into a tree.
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+)
+
+// maxDepth is the maximum number of nested schemas that are applied to a
+// single value, which stops reference cycles that don't descend into the
+// value, such as {"$ref":"#"}. The schemas applied to the members and
+// elements of a value are counted again.
+const maxDepth = 1000
+
+// Schema is a compiled JSON Schema. It's safe for concurrent use.
+type Schema struct {
+	root *node
+}
+
+// ValidationError is a value that failed a schema keyword.
+type ValidationError struct {
+	// Path is the GJSON path of the value, with each key escaped by
+	// gjson.Escape. The root is "@this".
+	Path string
+	// Pointer is the RFC 6901 JSON Pointer of the value.
+	Pointer string
+	// Keyword is the schema keyword that failed, such as "minimum".
+	Keyword string
+	// SchemaPointer is the JSON Pointer of the keyword in the schema.
+	SchemaPointer string
+	// Message describes the failure.
+	Message string
+	// Value is the value that failed.
+	Value gjson.Result
+}
+
+func (e *ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors are the errors returned by Validate.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	var sb strings.Builder
+	for i, err := range e {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(err.Error())
+	}
+	return sb.String()
+}
+
+// Compile compiles a JSON Schema.
+func Compile(schema string) (*Schema, error) {
+	if !gjson.Valid(schema) {
+		return nil, errors.New("schema: invalid json")
+	}
+	c := &compiler{
+		doc:     gjson.Parse(schema),
+		nodes:   make(map[string]*node),
+		anchors: make(map[string]string),
+	}
+	if id := c.doc.Get(`\$id`); id.Type == gjson.String {
+		c.id = strings.TrimSuffix(id.Str, "#")
+	}
+	root, err := c.compile("", c.doc)
+	if err != nil {
+		return nil, err
+	}
+	for len(c.refs) > 0 {
+		n := c.refs[len(c.refs)-1]
+		c.refs = c.refs[:len(c.refs)-1]
+		if n.ref, err = c.resolve(n.ptr, n.refURI); err != nil {
+			return nil, err
+		}
+	}
+	return &Schema{root: root}, nil
+}
+
+// MustCompile is like Compile but panics if the schema cannot be compiled.
+func MustCompile(schema string) *Schema {
+	s, err := Compile(schema)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Validate validates a value against the schema. It returns nil or
+// ValidationErrors with each value that failed.
+//
+//	s := schema.MustCompile(`{"properties":{"age":{"minimum":0}}}`)
+//	err := s.Validate(gjson.Parse(`{"age":-1}`))
+//	// age: must be >= 0
+func (s *Schema) Validate(value gjson.Result) error {
+	v := validator{}
+	v.validate(s.root, value, nil)
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// Valid reports whether a value is valid against the schema. It stops at
+// the first failure, which is faster than Validate.
+func (s *Schema) Valid(value gjson.Result) bool {
+	v := validator{quick: true}
+	v.validate(s.root, value, nil)
+	return len(v.errs) == 0
+}
+
+// Type bits of the "type" keyword.
+const (
+	typeNull = 1 << iota
+	typeBoolean
+	typeInteger
+	typeNumber
+	typeString
+	typeArray
+	typeObject
+)
+
+var typeNames = []string{
+	"null", "boolean", "integer", "number", "string", "array", "object",
+}
+
+type property struct {
+	name string
+	node *node
+}
+
+type patternProperty struct {
+	re   *regexp.Regexp
+	node *node
+}
+
+type dependency struct {
+	name     string
+	required []string
+	node     *node
+}
+
+// node is a compiled schema.
+type node struct {
+	ptr    string
+	always bool // boolean schema
+	never  bool // boolean schema
+
+	refURI string
+	ref    *node
+
+	types      int
+	enum       []gjson.Result
+	hasConst   bool
+	constValue gjson.Result
+
+	multipleOf       *decimal
+	minimum          gjson.Result
+	maximum          gjson.Result
+	exclusiveMinimum gjson.Result
+	exclusiveMaximum gjson.Result
+
+	minLength int
+	maxLength int
+	pattern   *regexp.Regexp
+
+	prefixItems []*node
+	items       *node
+	contains    *node
+	minItems    int
+	maxItems    int
+	minContains int
+	maxContains int
+	uniqueItems bool
+
+	properties           []property
+	patternProperties    []patternProperty
+	additionalProperties *node
+	propertyNames        *node
+	minProperties        int
+	maxProperties        int
+	required             []string
+	dependentRequired    []dependency
+	dependentSchemas     []dependency
+
+	allOf []*node
+	anyOf []*node
+	oneOf []*node
+	not   *node
+	ifs   *node
+	then  *node
+	els   *node
+}
+
+type compiler struct {
+	doc     gjson.Result
+	id      string
+	nodes   map[string]*node
+	anchors map[string]string
+	refs    []*node
+}
+
+func schemaError(ptr, format string, args ...interface{}) error {
+	return fmt.Errorf("schema: %s: %s", "#"+ptr, fmt.Sprintf(format, args...))
+}
+
+// escapePointer escapes a JSON Pointer reference token.
+func escapePointer(key string) string {
+	key = strings.Replace(key, "~", "~0", -1)
+	return strings.Replace(key, "/", "~1", -1)
+}
+
+func (c *compiler) compile(ptr string, s gjson.Result) (*node, error) {
+	if n, ok := c.nodes[ptr]; ok {
+		return n, nil
+	}
+	n := &node{
+		ptr: ptr, minLength: -1, maxLength: -1, minItems: -1, maxItems: -1,
+		minContains: -1, maxContains: -1, minProperties: -1, maxProperties: -1,
+	}
+	c.nodes[ptr] = n
+	switch {
+	case s.Type == gjson.True:
+		n.always = true
+		return n, nil
+	case s.Type == gjson.False:
+		n.never = true
+		return n, nil
+	case !s.IsObject():
+		return nil, schemaError(ptr, "schema must be an object or boolean")
+	}
+	var err error
+	s.ForEach(func(key, value gjson.Result) bool {
+		err = c.keyword(n, key.Str, value)
+		return err == nil
+	})
+	return n, err
+}
+
+func (c *compiler) subs(ptr string, s gjson.Result) ([]*node, error) {
+	if !s.IsArray() {
+		return nil, schemaError(ptr, "must be an array")
+	}
+	var nodes []*node
+	var err error
+	s.ForEach(func(_, value gjson.Result) bool {
+		var n *node
+		n, err = c.compile(ptr+"/"+strconv.Itoa(len(nodes)), value)
+		nodes = append(nodes, n)
+		return err == nil
+	})
+	return nodes, err
+}
+
+func (c *compiler) subMap(ptr string, s gjson.Result,
+	fn func(key string, n *node) error,
+) error {
+	if !s.IsObject() {
+		return schemaError(ptr, "must be an object")
+	}
+	var err error
+	s.ForEach(func(key, value gjson.Result) bool {
+		var n *node
+		n, err = c.compile(ptr+"/"+escapePointer(key.Str), value)
+		if err == nil {
+			err = fn(key.Str, n)
+		}
+		return err == nil
+	})
+	return err
+}
+
+func count(ptr string, s gjson.Result) (int, error) {
+	n, ok := s.Int(), s.Type == gjson.Number
+	if !ok || n < 0 || float64(n) != s.Float() {
+		return 0, schemaError(ptr, "must be a non-negative integer")
+	}
+	return int(n), nil
+}
+
+func stringList(ptr string, s gjson.Result) ([]string, error) {
+	if !s.IsArray() {
+		return nil, schemaError(ptr, "must be an array of strings")
+	}
+	var list []string
+	var err error
+	s.ForEach(func(_, value gjson.Result) bool {
+		if value.Type != gjson.String {
+			err = schemaError(ptr, "must be an array of strings")
+			return false
+		}
+		list = append(list, value.Str)
+		return true
+	})
+	return list, err
+}
+
+func (c *compiler) keyword(n *node, kw string, s gjson.Result) error {
+	ptr := n.ptr + "/" + escapePointer(kw)
+	var err error
+	switch kw {
+	case "$ref":
+		if s.Type != gjson.String {
+			return schemaError(ptr, "must be a string")
+		}
+		n.refURI = s.Str
+		c.refs = append(c.refs, n)
+	case "$dynamicRef", "$dynamicAnchor":
+		// the dynamic scope isn't tracked, so these can't be validated
+		return schemaError(ptr, "unsupported keyword %q", kw)
+	case "$anchor":
+		if s.Type != gjson.String {
+			return schemaError(ptr, "must be a string")
+		}
+		c.anchors[s.Str] = n.ptr
+	case "$defs", "definitions":
+		err = c.subMap(ptr, s, func(string, *node) error { return nil })
+	case "type":
+		if s.Type == gjson.String {
+			s = gjson.Parse("[" + s.Raw + "]")
+		}
+		list, err := stringList(ptr, s)
+		if err != nil {
+			return err
+		}
+		for _, name := range list {
+			i := 0
+			for i < len(typeNames) && typeNames[i] != name {
+				i++
+			}
+			if i == len(typeNames) {
+				return schemaError(ptr, "unknown type %q", name)
+			}
+			n.types |= 1 << i
+		}
+	case "enum":
+		if !s.IsArray() {
+			return schemaError(ptr, "must be an array")
+		}
+		n.enum = s.Array()
+	case "const":
+		n.hasConst, n.constValue = true, s
+	case "multipleOf":
+		d, ok := parseDecimal(s.Raw)
+		if s.Type != gjson.Number || !ok || d.neg || d.digits.Sign() == 0 {
+			return schemaError(ptr, "must be a number greater than 0")
+		}
+		n.multipleOf = &d
+	case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum":
+		if s.Type != gjson.Number {
+			return schemaError(ptr, "must be a number")
+		}
+		switch kw {
+		case "minimum":
+			n.minimum = s
+		case "maximum":
+			n.maximum = s
+		case "exclusiveMinimum":
+			n.exclusiveMinimum = s
+		case "exclusiveMaximum":
+			n.exclusiveMaximum = s
+		}
+	case "minLength":
+		n.minLength, err = count(ptr, s)
+	case "maxLength":
+		n.maxLength, err = count(ptr, s)
+	case "pattern":
+		if s.Type != gjson.String {
+			return schemaError(ptr, "must be a string")
+		}
+		if n.pattern, err = regexp.Compile(s.Str); err != nil {
+			return schemaError(ptr, "%v", err)
+		}
+	case "prefixItems":
+		n.prefixItems, err = c.subs(ptr, s)
+	case "items":
+		n.items, err = c.compile(ptr, s)
+	case "contains":
+		n.contains, err = c.compile(ptr, s)
+	case "minItems":
+		n.minItems, err = count(ptr, s)
+	case "maxItems":
+		n.maxItems, err = count(ptr, s)
+	case "minContains":
+		n.minContains, err = count(ptr, s)
+	case "maxContains":
+		n.maxContains, err = count(ptr, s)
+	case "uniqueItems":
+		n.uniqueItems = s.Type == gjson.True
+	case "properties":
+		err = c.subMap(ptr, s, func(key string, sub *node) error {
+			n.properties = append(n.properties, property{key, sub})
+			return nil
+		})
+	case "patternProperties":
+		err = c.subMap(ptr, s, func(key string, sub *node) error {
+			re, err := regexp.Compile(key)
+			if err != nil {
+				return schemaError(ptr, "%v", err)
+			}
+			n.patternProperties = append(n.patternProperties,
+				patternProperty{re, sub})
+			return nil
+		})
+	case "additionalProperties":
+		n.additionalProperties, err = c.compile(ptr, s)
+	case "propertyNames":
+		n.propertyNames, err = c.compile(ptr, s)
+	case "minProperties":
+		n.minProperties, err = count(ptr, s)
+	case "maxProperties":
+		n.maxProperties, err = count(ptr, s)
+	case "required":
+		n.required, err = stringList(ptr, s)
+	case "dependentRequired":
+		if !s.IsObject() {
+			return schemaError(ptr, "must be an object")
+		}
+		s.ForEach(func(key, value gjson.Result) bool {
+			var list []string
+			list, err = stringList(ptr+"/"+escapePointer(key.Str), value)
+			n.dependentRequired = append(n.dependentRequired,
+				dependency{name: key.Str, required: list})
+			return err == nil
+		})
+	case "dependentSchemas":
+		err = c.subMap(ptr, s, func(key string, sub *node) error {
+			n.dependentSchemas = append(n.dependentSchemas,
+				dependency{name: key, node: sub})
+			return nil
+		})
+	case "allOf":
+		n.allOf, err = c.subs(ptr, s)
+	case "anyOf":
+		n.anyOf, err = c.subs(ptr, s)
+	case "oneOf":
+		n.oneOf, err = c.subs(ptr, s)
+	case "not":
+		n.not, err = c.compile(ptr, s)
+	case "if":
+		n.ifs, err = c.compile(ptr, s)
+	case "then":
+		n.then, err = c.compile(ptr, s)
+	case "else":
+		n.els, err = c.compile(ptr, s)
+	}
+	return err
+}
+
+// resolve returns the schema of a local reference.
+func (c *compiler) resolve(ptr, uri string) (*node, error) {
+	ref := uri
+	if c.id != "" && strings.HasPrefix(ref, c.id) {
+		ref = ref[len(c.id):]
+	}
+	if ref == "" {
+		ref = "#"
+	}
+	if ref[0] != '#' {
+		return nil, schemaError(ptr, "unsupported non-local $ref %q", uri)
+	}
+	frag, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil, schemaError(ptr, "invalid $ref %q", uri)
+	}
+	if frag != "" && frag[0] != '/' {
+		target, ok := c.anchors[frag]
+		if !ok {
+			return nil, schemaError(ptr, "unknown anchor in $ref %q", uri)
+		}
+		return c.nodes[target], nil
+	}
+	if n, ok := c.nodes[frag]; ok {
+		return n, nil
+	}
+	s := c.doc
+	if frag != "" {
+		for _, token := range strings.Split(frag[1:], "/") {
+			token = strings.Replace(token, "~1", "/", -1)
+			token = strings.Replace(token, "~0", "~", -1)
+			s = s.Get(gjson.Escape(token))
+		}
+	}
+	if !s.Exists() {
+		return nil, schemaError(ptr, "unresolved $ref %q", uri)
+	}
+	return c.compile(frag, s)
+}
+
+// location is the location of a value in the validated document, which is
+// only turned into a path when a value fails.
+type location struct {
+	parent *location
+	key    string
+	index  int // -1 for object keys
+}
+
+func (l *location) tokens() []*location {
+	var list []*location
+	for ; l != nil; l = l.parent {
+		list = append(list, l)
+	}
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+	return list
+}
+
+func (l *location) path() string {
+	if l == nil {
+		return "@this"
+	}
+	var sb strings.Builder
+	for i, t := range l.tokens() {
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+		if t.index >= 0 {
+			sb.WriteString(strconv.Itoa(t.index))
+		} else {
+			sb.WriteString(gjson.Escape(t.key))
+		}
+	}
+	return sb.String()
+}
+
+func (l *location) pointer() string {
+	var sb strings.Builder
+	for _, t := range l.tokens() {
+		sb.WriteByte('/')
+		if t.index >= 0 {
+			sb.WriteString(strconv.Itoa(t.index))
+		} else {
+			sb.WriteString(escapePointer(t.key))
+		}
+	}
+	return sb.String()
+}
+
+type validator struct {
+	errs  ValidationErrors
+	quick bool      // stop at the first error
+	at    *location // the value that depth counts the schemas of
+	depth int
+}
+
+func (v *validator) done() bool {
+	return v.quick && len(v.errs) > 0
+}
+
+func (v *validator) fail(n *node, kw string, value gjson.Result,
+	loc *location, format string, args ...interface{},
+) {
+	if v.done() {
+		return
+	}
+	sptr := n.ptr
+	if kw != "" {
+		sptr += "/" + kw
+	}
+	v.errs = append(v.errs, &ValidationError{
+		Path:          loc.path(),
+		Pointer:       loc.pointer(),
+		Keyword:       kw,
+		SchemaPointer: sptr,
+		Message:       fmt.Sprintf(format, args...),
+		Value:         value,
+	})
+}
+
+// valid reports whether a value is valid against a schema without
+// recording any errors.
+func (v *validator) valid(n *node, value gjson.Result, loc *location) bool {
+	sub := validator{quick: true, at: v.at, depth: v.depth}
+	sub.validate(n, value, loc)
+	return len(sub.errs) == 0
+}
+
+func typeOf(value gjson.Result) int {
+	switch value.Type {
+	case gjson.Null:
+		return typeNull
+	case gjson.False, gjson.True:
+		return typeBoolean
+	case gjson.Number:
+		return typeNumber
+	case gjson.String:
+		return typeString
+	}
+	if value.IsArray() {
+		return typeArray
+	}
+	return typeObject
+}
+
+// decimal is a number split into its significant digits and a base 10
+// exponent, such that the value is digits*10^exp, like gjson splits a number
+// for Result.Decimal. Numbers with huge exponents, such as 1e9999999, are
+// kept exact without expanding them.
+type decimal struct {
+	neg    bool
+	digits *big.Int // without trailing zeros, unless it's zero
+	ndigit int      // the number of digits
+	exp    int
+	raw    string
+}
+
+// parseDecimal parses a number in the JSON number grammar.
+func parseDecimal(raw string) (decimal, bool) {
+	d := decimal{raw: raw}
+	var i int
+	if i < len(raw) && raw[i] == '-' {
+		d.neg = true
+		i++
+	}
+	s := i
+	for i < len(raw) && raw[i] >= '0' && raw[i] <= '9' {
+		i++
+	}
+	ipart := raw[s:i]
+	var fpart string
+	if i < len(raw) && raw[i] == '.' {
+		i++
+		s = i
+		for i < len(raw) && raw[i] >= '0' && raw[i] <= '9' {
+			i++
+		}
+		fpart = raw[s:i]
+		if fpart == "" {
+			return d, false
+		}
+	}
+	if ipart == "" {
+		return d, false
+	}
+	if i < len(raw) && (raw[i] == 'e' || raw[i] == 'E') {
+		i++
+		var eneg bool
+		if i < len(raw) && (raw[i] == '-' || raw[i] == '+') {
+			eneg = raw[i] == '-'
+			i++
+		}
+		s = i
+		for i < len(raw) && raw[i] >= '0' && raw[i] <= '9' {
+			if i-s >= 9 {
+				// exponent out of range
+				return d, false
+			}
+			d.exp = d.exp*10 + int(raw[i]-'0')
+			i++
+		}
+		if i == s {
+			return d, false
+		}
+		if eneg {
+			d.exp = -d.exp
+		}
+	}
+	if i != len(raw) {
+		return d, false
+	}
+	digits := strings.TrimLeft(ipart+fpart, "0")
+	d.exp -= len(fpart)
+	for len(digits) > 0 && digits[len(digits)-1] == '0' {
+		digits = digits[:len(digits)-1]
+		d.exp++
+	}
+	if digits == "" {
+		digits, d.exp = "0", 0
+	}
+	d.ndigit = len(digits)
+	d.digits, _ = new(big.Int).SetString(digits, 10)
+	return d, true
+}
+
+// multipleOf reports whether d is an integer multiple of m, which is greater
+// than zero.
+func (d decimal) multipleOf(m *decimal) bool {
+	if d.digits.Sign() == 0 {
+		return true
+	}
+	pow := new(big.Int)
+	e := d.exp - m.exp
+	if e < 0 {
+		if -e > d.ndigit {
+			// m*10^-e is larger than the digits of d
+			return false
+		}
+		pow.Exp(big.NewInt(10), big.NewInt(int64(-e)), nil)
+		div := pow.Mul(pow, m.digits)
+		return new(big.Int).Mod(d.digits, div).Sign() == 0
+	}
+	// Only the factors of 2 and 5 in m need the powers of 10, so an exponent
+	// past the bit length of m doesn't change the result.
+	if e > m.digits.BitLen() {
+		e = m.digits.BitLen()
+	}
+	pow.Exp(big.NewInt(10), big.NewInt(int64(e)), nil)
+	x := pow.Mul(pow, d.digits)
+	return x.Mod(x, m.digits).Sign() == 0
+}
+
+func isInteger(value gjson.Result) bool {
+	if strings.IndexAny(value.Raw, ".eE") == -1 {
+		return true
+	}
+	d, ok := parseDecimal(value.Raw)
+	return ok && d.exp >= 0
+}
+
+func typeList(types int) string {
+	var names []string
+	for i, name := range typeNames {
+		if types&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+func (v *validator) validate(n *node, value gjson.Result, loc *location) {
+	if !value.Exists() {
+		v.fail(n, "", value, loc, "value does not exist")
+		return
+	}
+	switch {
+	case n.always:
+		return
+	case n.never:
+		v.fail(n, "", value, loc, "no value is allowed")
+		return
+	}
+	at, depth := v.at, v.depth
+	if loc != at {
+		v.at, v.depth = loc, 0
+	}
+	defer func() { v.at, v.depth = at, depth }()
+	if v.depth++; v.depth > maxDepth {
+		v.fail(n, "", value, loc, "schema is nested too deeply")
+		return
+	}
+	if n.ref != nil {
+		v.validate(n.ref, value, loc)
+	}
+	typ := typeOf(value)
+	if n.types != 0 && n.types&typ == 0 && (typ != typeNumber ||
+		n.types&typeInteger == 0 || !isInteger(value)) {
+		v.fail(n, "type", value, loc, "expected %s, got %s",
+			typeList(n.types), typeList(typ))
+	}
+	if n.enum != nil {
+		var found bool
+		for _, e := range n.enum {
+			if value.Equal(e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(n, "enum", value, loc, "must be one of the enum values")
+		}
+	}
+	if n.hasConst && !value.Equal(n.constValue) {
+		v.fail(n, "const", value, loc, "must be %s", n.constValue.Raw)
+	}
+	switch typ {
+	case typeNumber:
+		v.number(n, value, loc)
+	case typeString:
+		v.string(n, value, loc)
+	case typeArray:
+		v.array(n, value, loc)
+	case typeObject:
+		v.object(n, value, loc)
+	}
+	v.applicators(n, value, loc)
+}
+
+func (v *validator) number(n *node, value gjson.Result, loc *location) {
+	if n.minimum.Exists() && value.Compare(n.minimum) < 0 {
+		v.fail(n, "minimum", value, loc, "must be >= %s", n.minimum.Raw)
+	}
+	if n.maximum.Exists() && value.Compare(n.maximum) > 0 {
+		v.fail(n, "maximum", value, loc, "must be <= %s", n.maximum.Raw)
+	}
+	if n.exclusiveMinimum.Exists() && value.Compare(n.exclusiveMinimum) <= 0 {
+		v.fail(n, "exclusiveMinimum", value, loc, "must be > %s",
+			n.exclusiveMinimum.Raw)
+	}
+	if n.exclusiveMaximum.Exists() && value.Compare(n.exclusiveMaximum) >= 0 {
+		v.fail(n, "exclusiveMaximum", value, loc, "must be < %s",
+			n.exclusiveMaximum.Raw)
+	}
+	if n.multipleOf != nil {
+		d, ok := parseDecimal(value.Raw)
+		if !ok || !d.multipleOf(n.multipleOf) {
+			v.fail(n, "multipleOf", value, loc, "must be a multiple of %s",
+				n.multipleOf.raw)
+		}
+	}
+}
+
+func (v *validator) string(n *node, value gjson.Result, loc *location) {
+	if n.minLength >= 0 || n.maxLength >= 0 {
+		length := utf8.RuneCountInString(value.Str)
+		if n.minLength >= 0 && length < n.minLength {
+			v.fail(n, "minLength", value, loc,
+				"length must be >= %d", n.minLength)
+		}
+		if n.maxLength >= 0 && length > n.maxLength {
+			v.fail(n, "maxLength", value, loc,
+				"length must be <= %d", n.maxLength)
+		}
+	}
+	if n.pattern != nil && !n.pattern.MatchString(value.Str) {
+		v.fail(n, "pattern", value, loc, "must match pattern %q",
+			n.pattern.String())
+	}
+}
+
+func (v *validator) array(n *node, value gjson.Result, loc *location) {
+	var count, contains int
+	var seen map[uint64][]gjson.Result
+	var duplicate bool
+	if n.uniqueItems {
+		seen = make(map[uint64][]gjson.Result)
+	}
+	value.ForEach(func(_, elem gjson.Result) bool {
+		eloc := &location{parent: loc, index: count}
+		if count < len(n.prefixItems) {
+			v.validate(n.prefixItems[count], elem, eloc)
+		} else if n.items != nil {
+			v.validate(n.items, elem, eloc)
+		}
+		if n.contains != nil && v.valid(n.contains, elem, eloc) {
+			contains++
+		}
+		if n.uniqueItems && !duplicate {
+			hash := elem.Hash()
+			for _, other := range seen[hash] {
+				if elem.Equal(other) {
+					duplicate = true
+					break
+				}
+			}
+			seen[hash] = append(seen[hash], elem)
+		}
+		count++
+		return !v.done()
+	})
+	if n.minItems >= 0 && count < n.minItems {
+		v.fail(n, "minItems", value, loc,
+			"must have at least %d items", n.minItems)
+	}
+	if n.maxItems >= 0 && count > n.maxItems {
+		v.fail(n, "maxItems", value, loc,
+			"must have at most %d items", n.maxItems)
+	}
+	if duplicate {
+		v.fail(n, "uniqueItems", value, loc, "items must be unique")
+	}
+	if n.contains != nil {
+		min := n.minContains
+		if min < 0 {
+			min = 1
+		}
+		if contains < min {
+			kw := "contains"
+			if n.minContains >= 0 {
+				kw = "minContains"
+			}
+			v.fail(n, kw, value, loc,
+				"must contain at least %d matching items", min)
+		}
+		if n.maxContains >= 0 && contains > n.maxContains {
+			v.fail(n, "maxContains", value, loc,
+				"must contain at most %d matching items", n.maxContains)
+		}
+	}
+}
+
+func (v *validator) object(n *node, value gjson.Result, loc *location) {
+	var count int
+	var found []bool
+	var deps []int
+	if len(n.required) > 0 {
+		found = make([]bool, len(n.required))
+	}
+	value.ForEach(func(key, elem gjson.Result) bool {
+		count++
+		for i, name := range n.required {
+			if name == key.Str {
+				found[i] = true
+			}
+		}
+		for i, dep := range n.dependentRequired {
+			if dep.name == key.Str {
+				deps = append(deps, i)
+			}
+		}
+		eloc := &location{parent: loc, key: key.Str, index: -1}
+		if n.propertyNames != nil {
+			v.validate(n.propertyNames, key, eloc)
+		}
+		matched := false
+		for _, prop := range n.properties {
+			if prop.name == key.Str {
+				v.validate(prop.node, elem, eloc)
+				matched = true
+			}
+		}
+		for _, prop := range n.patternProperties {
+			if prop.re.MatchString(key.Str) {
+				v.validate(prop.node, elem, eloc)
+				matched = true
+			}
+		}
+		if !matched && n.additionalProperties != nil {
+			v.validate(n.additionalProperties, elem, eloc)
+		}
+		return !v.done()
+	})
+	if n.minProperties >= 0 && count < n.minProperties {
+		v.fail(n, "minProperties", value, loc,
+			"must have at least %d properties", n.minProperties)
+	}
+	if n.maxProperties >= 0 && count > n.maxProperties {
+		v.fail(n, "maxProperties", value, loc,
+			"must have at most %d properties", n.maxProperties)
+	}
+	for i, name := range n.required {
+		if !found[i] {
+			v.fail(n, "required", value, loc,
+				"missing required property %q", name)
+		}
+	}
+	for _, i := range deps {
+		dep := n.dependentRequired[i]
+		for _, name := range dep.required {
+			if !value.Get(gjson.Escape(name)).Exists() {
+				v.fail(n, "dependentRequired", value, loc,
+					"property %q requires property %q", dep.name, name)
+			}
+		}
+	}
+	for _, dep := range n.dependentSchemas {
+		if value.Get(gjson.Escape(dep.name)).Exists() {
+			v.validate(dep.node, value, loc)
+		}
+	}
+}
+
+func (v *validator) applicators(n *node, value gjson.Result, loc *location) {
+	for _, sub := range n.allOf {
+		v.validate(sub, value, loc)
+	}
+	if n.anyOf != nil {
+		var ok bool
+		for _, sub := range n.anyOf {
+			if ok = v.valid(sub, value, loc); ok {
+				break
+			}
+		}
+		if !ok {
+			v.fail(n, "anyOf", value, loc, "must match a schema in anyOf")
+		}
+	}
+	if n.oneOf != nil {
+		var matches int
+		for _, sub := range n.oneOf {
+			if v.valid(sub, value, loc) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			v.fail(n, "oneOf", value, loc,
+				"must match exactly one schema in oneOf, matched %d", matches)
+		}
+	}
+	if n.not != nil && v.valid(n.not, value, loc) {
+		v.fail(n, "not", value, loc, "must not match the schema in not")
+	}
+	if n.ifs != nil {
+		if v.valid(n.ifs, value, loc) {
+			if n.then != nil {
+				v.validate(n.then, value, loc)
+			}
+		} else if n.els != nil {
+			v.validate(n.els, value, loc)
+		}
+	}
+}
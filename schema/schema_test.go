@@ -0,0 +1,244 @@
+package schema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func assert(t testing.TB, cond bool) {
+	t.Helper()
+	if !cond {
+		t.Fatal("assertion failed")
+	}
+}
+
+func testValid(t *testing.T, schema string, valid bool, docs ...string) {
+	t.Helper()
+	s, err := Compile(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, doc := range docs {
+		err := s.Validate(gjson.Parse(doc))
+		if (err == nil) != valid || s.Valid(gjson.Parse(doc)) != valid {
+			t.Fatalf("%s: %s: expected valid=%t, got %v", schema, doc, valid,
+				err)
+		}
+	}
+}
+
+func TestKeywords(t *testing.T) {
+	testValid(t, `true`, true, `1`, `{}`)
+	testValid(t, `false`, false, `1`, `{}`)
+	testValid(t, `{"type":"integer"}`, true, `1`, `1.0`, `-2e3`)
+	testValid(t, `{"type":"integer"}`, false, `1.5`, `"1"`)
+	testValid(t, `{"type":["string","null"]}`, true, `"a"`, `null`)
+	testValid(t, `{"type":["string","null"]}`, false, `false`, `[]`)
+	testValid(t, `{"enum":[1,"a",{"b":[2]}]}`, true, `1.0`, `{"b":[2]}`)
+	testValid(t, `{"enum":[1,"a",{"b":[2]}]}`, false, `2`, `{"b":[]}`)
+	testValid(t, `{"const":{"a":1,"b":2}}`, true, `{"b":2,"a":1}`)
+	testValid(t, `{"const":{"a":1,"b":2}}`, false, `{"a":1}`)
+	testValid(t, `{"minimum":1,"exclusiveMaximum":3}`, true, `1`, `2.99`, `"a"`)
+	testValid(t, `{"minimum":1,"exclusiveMaximum":3}`, false, `0.9`, `3`)
+	testValid(t, `{"maximum":18446744073709551615}`, true,
+		`18446744073709551615`)
+	testValid(t, `{"maximum":18446744073709551615}`, false,
+		`18446744073709551616`)
+	testValid(t, `{"multipleOf":0.1}`, true, `0.3`, `3`, `1e1`)
+	testValid(t, `{"multipleOf":0.1}`, false, `0.35`)
+	testValid(t, `{"type":"integer","multipleOf":2}`, true, `1e9999999`,
+		`-4.0e1`, `0`, `0.0`, `1200e-2`)
+	testValid(t, `{"type":"integer"}`, false, `1e-9999999`, `10.5e-1`)
+	testValid(t, `{"multipleOf":2}`, false, `3e0`, `1e-9999999`, `1`)
+	testValid(t, `{"multipleOf":1.5e-999999}`, true, `3e-999999`, `3e9999`)
+	testValid(t, `{"multipleOf":1.5e-999999}`, false, `1e9999`, `1e-999999`)
+	testValid(t, `{"multipleOf":3e9999999}`, true, `6e9999999`, `3e10000000`)
+	testValid(t, `{"multipleOf":3e9999999}`, false, `1e9999999`, `3`)
+	testValid(t, `{"minLength":2,"maxLength":3}`, true, `"日本"`, `"abc"`, `1`)
+	testValid(t, `{"minLength":2,"maxLength":3}`, false, `"a"`, `"abcd"`)
+	testValid(t, `{"pattern":"^a+$"}`, true, `"aaa"`)
+	testValid(t, `{"pattern":"^a+$"}`, false, `"ab"`)
+	testValid(t, `{"prefixItems":[{"type":"string"}],"items":{"type":"number"}}`,
+		true, `["a",1,2]`, `[]`)
+	testValid(t, `{"prefixItems":[{"type":"string"}],"items":{"type":"number"}}`,
+		false, `[1]`, `["a","b"]`)
+	testValid(t, `{"prefixItems":[true],"items":false}`, false, `[1,2]`)
+	testValid(t, `{"minItems":1,"maxItems":2,"uniqueItems":true}`, true,
+		`[1]`, `[1,{"a":1}]`)
+	testValid(t, `{"minItems":1,"maxItems":2,"uniqueItems":true}`, false,
+		`[]`, `[1,2,3]`, `[1,1.0]`, `[{"a":1,"b":2},{"b":2,"a":1}]`)
+	testValid(t, `{"contains":{"const":1},"maxContains":2}`, true, `[1,2,1]`)
+	testValid(t, `{"contains":{"const":1},"maxContains":2}`, false,
+		`[2]`, `[1,1,1]`)
+	testValid(t, `{"contains":{"const":1},"minContains":0}`, true, `[]`)
+	testValid(t, `{"required":["a","b"],"minProperties":2}`, true,
+		`{"a":1,"b":2}`)
+	testValid(t, `{"required":["a","b"],"minProperties":2}`, false,
+		`{"a":1}`, `{"a":1,"c":2}`)
+	testValid(t, `{"properties":{"a.b":{"type":"string"}},
+		"patternProperties":{"^x":{"type":"number"}},
+		"additionalProperties":false}`, true, `{"a.b":"1","x1":1}`)
+	testValid(t, `{"properties":{"a.b":{"type":"string"}},
+		"patternProperties":{"^x":{"type":"number"}},
+		"additionalProperties":false}`, false,
+		`{"a.b":1}`, `{"x1":"1"}`, `{"y":1}`)
+	testValid(t, `{"propertyNames":{"maxLength":2},"maxProperties":1}`, true,
+		`{"ab":1}`)
+	testValid(t, `{"propertyNames":{"maxLength":2},"maxProperties":1}`, false,
+		`{"abc":1}`, `{"a":1,"b":2}`)
+	testValid(t, `{"dependentRequired":{"a":["b"]},
+		"dependentSchemas":{"c":{"required":["d"]}}}`, true,
+		`{"b":1}`, `{"a":1,"b":1}`, `{"c":1,"d":1}`)
+	testValid(t, `{"dependentRequired":{"a":["b"]},
+		"dependentSchemas":{"c":{"required":["d"]}}}`, false,
+		`{"a":1}`, `{"c":1}`)
+	testValid(t, `{"allOf":[{"minimum":1},{"maximum":2}]}`, true, `1`)
+	testValid(t, `{"allOf":[{"minimum":1},{"maximum":2}]}`, false, `3`)
+	testValid(t, `{"anyOf":[{"type":"string"},{"minimum":2}]}`, true, `"a"`, `3`)
+	testValid(t, `{"anyOf":[{"type":"string"},{"minimum":2}]}`, false, `1`)
+	testValid(t, `{"oneOf":[{"type":"integer"},{"minimum":2}]}`, true, `1`, `2.5`)
+	testValid(t, `{"oneOf":[{"type":"integer"},{"minimum":2}]}`, false, `3`)
+	testValid(t, `{"not":{"type":"null"}}`, true, `1`)
+	testValid(t, `{"not":{"type":"null"}}`, false, `null`)
+	testValid(t, `{"if":{"type":"string"},"then":{"minLength":2},
+		"else":{"type":"number"}}`, true, `"ab"`, `1`)
+	testValid(t, `{"if":{"type":"string"},"then":{"minLength":2},
+		"else":{"type":"number"}}`, false, `"a"`, `true`)
+	testValid(t, `{"title":"x","format":"email","$comment":"y"}`, true, `"a"`)
+}
+
+func TestRefs(t *testing.T) {
+	tree := `{
+		"$id": "https://example.com/tree",
+		"$defs": {
+			"node": {
+				"$anchor": "node",
+				"type": "object",
+				"properties": {
+					"value": {"type": "number"},
+					"children": {"type": "array", "items": {"$ref": "#node"}}
+				}
+			}
+		},
+		"$ref": "https://example.com/tree#/$defs/node"
+	}`
+	testValid(t, tree, true, `{"value":1,"children":[{"value":2,"children":[]}]}`)
+	testValid(t, tree, false, `{"value":1,"children":[{"value":"2"}]}`)
+	testValid(t, `{"properties":{"a":{"type":"string"},"b":{"$ref":"#/properties/a"}}}`,
+		false, `{"b":1}`)
+	testValid(t, `{"x":[{"type":"string"}],"$ref":"#/x/0"}`,
+		false, `1`)
+	testValid(t, `{"$defs":{"a~/b":{"type":"string"}},"$ref":"#/$defs/a~0~1b"}`,
+		false, `1`)
+	testValid(t, `{"$defs":{"a b":{"type":"string"}},"$ref":"#/$defs/a%20b"}`,
+		false, `1`)
+	testValid(t, `{"$ref":"#"}`, false, `1`)
+	testValid(t, `{"allOf":[{"$ref":"#"}]}`, false, `{"a":1}`)
+
+	// the schemas applied to a value are counted, not the depth of the value
+	deep := strings.Repeat(`{"a":`, 2000) + `1` + strings.Repeat(`}`, 2000)
+	testValid(t, `{"$defs":{"n":{"properties":{"a":{"$ref":"#/$defs/n"}}}},
+		"$ref":"#/$defs/n"}`, true, deep)
+	testValid(t, `{"$defs":{"n":{"properties":{"a":{"$ref":"#/$defs/n"}},
+		"type":"object"}},"$ref":"#/$defs/n"}`, false, deep)
+
+	for _, schema := range []string{
+		`{"$ref":"other.json"}`,
+		`{"$ref":"#/$defs/missing"}`,
+		`{"$ref":"#missing"}`,
+		`{"$dynamicRef":"#node"}`,
+		`{"$defs":{"a":{"$dynamicAnchor":"node"}},"$ref":"#node"}`,
+		`{"type":"integer_"}`,
+		`{"minLength":-1}`,
+		`{"pattern":"("}`,
+		`{"properties":[]}`,
+		`{"allOf":{}}`,
+		`1`,
+		`{`,
+	} {
+		_, err := Compile(schema)
+		assert(t, err != nil)
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	s := MustCompile(`{
+		"type": "object",
+		"required": ["name"],
+		"properties": {
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"meta": {"additionalProperties": {"maximum": 10}}
+		}
+	}`)
+	err := s.Validate(gjson.Parse(
+		`{"tags":["a",1],"meta":{"x.y":11,"a/b":1}}`))
+	var errs ValidationErrors
+	assert(t, errors.As(err, &errs))
+	assert(t, len(errs) == 3)
+	assert(t, errs[0].Path == "tags.1")
+	assert(t, errs[0].Pointer == "/tags/1")
+	assert(t, errs[0].Keyword == "type")
+	assert(t, errs[0].SchemaPointer == "/properties/tags/items/type")
+	assert(t, errs[0].Value.Raw == "1")
+	assert(t, errs[1].Path == `meta.x\.y`)
+	assert(t, errs[1].Pointer == "/meta/x.y")
+	assert(t, errs[1].Keyword == "maximum")
+	assert(t, errs[2].Path == "@this")
+	assert(t, errs[2].Pointer == "")
+	assert(t, errs[2].Keyword == "required")
+	assert(t, err.Error() == "tags.1: expected string, got number\n"+
+		"meta.x\\.y: must be <= 10\n"+
+		`@this: missing required property "name"`)
+	for _, e := range errs {
+		assert(t, gjson.Get(
+			`{"tags":["a",1],"meta":{"x.y":11,"a/b":1}}`, e.Path).Exists())
+	}
+
+	assert(t, s.Validate(gjson.Parse(`{"name":"x","tags":[]}`)) == nil)
+	assert(t, s.Validate(gjson.Result{}) != nil)
+	assert(t, !s.Valid(gjson.Get(`{}`, "missing")))
+}
+
+func BenchmarkValidate(b *testing.B) {
+	s := MustCompile(`{
+		"type": "array",
+		"items": {
+			"type": "object",
+			"required": ["id", "name"],
+			"properties": {
+				"id": {"type": "integer", "minimum": 0},
+				"name": {"type": "string", "maxLength": 32},
+				"tags": {"type": "array", "items": {"type": "string"}}
+			}
+		}
+	}`)
+	doc := gjson.Parse(`[{"id":1,"name":"Janet","tags":["a","b"]},` +
+		`{"id":2,"name":"Carol","tags":[]},{"id":3,"name":"Sam"}]`)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if !s.Valid(doc) {
+			b.Fatal("invalid")
+		}
+	}
+}
+
+func TestModifierArgSchemas(t *testing.T) {
+	for _, info := range gjson.Modifiers() {
+		if info.ArgSchema != "" {
+			_, err := Compile(info.ArgSchema)
+			assert(t, err == nil)
+		}
+	}
+	for _, info := range gjson.Modifiers() {
+		if info.Name == "date" {
+			testValid(t, info.ArgSchema, true, `"2006-01-02"`,
+				`{"before":1710108245}`, `{"after":"2024-03-10T22:04:05Z"}`,
+				`{"equal":1710108245000,"tz":"UTC"}`)
+			testValid(t, info.ArgSchema, false, `1`, `{"before":true}`,
+				`{"add":1}`)
+		}
+	}
+}
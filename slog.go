@@ -0,0 +1,59 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+
+package gjson
+
+import "log/slog"
+
+// LogValue implements slog.LogValuer. Objects become groups, strings,
+// numbers and booleans become their slog kinds, and arrays and integers that
+// do not fit in 64 bits are logged as raw json.
+func (t Result) LogValue() slog.Value {
+	switch t.Type {
+	case String:
+		return slog.StringValue(t.Str)
+	case True:
+		return slog.BoolValue(true)
+	case False:
+		return slog.BoolValue(false)
+	case Number:
+		if n, err := t.IntE(); err == nil {
+			return slog.Int64Value(n)
+		}
+		if n, err := t.UintE(); err == nil {
+			return slog.Uint64Value(n)
+		}
+		if _, ok := t.BigInt(); ok {
+			return slog.AnyValue(logJSON(t.rawJSON()))
+		}
+		return slog.Float64Value(t.Num)
+	case JSON:
+		if t.IsObject() {
+			var attrs []slog.Attr
+			t.ForEach(func(key, value Result) bool {
+				attrs = append(attrs, slog.Attr{
+					Key:   key.Str,
+					Value: value.LogValue(),
+				})
+				return true
+			})
+			return slog.GroupValue(attrs...)
+		}
+		return slog.AnyValue(logJSON(t.Raw))
+	}
+	return slog.AnyValue(nil)
+}
+
+// logJSON is raw json that is written as is by the slog handlers.
+type logJSON string
+
+func (j logJSON) MarshalJSON() ([]byte, error) {
+	return []byte(j), nil
+}
+
+func (j logJSON) MarshalText() ([]byte, error) {
+	return []byte(j), nil
+}
@@ -0,0 +1,37 @@
+//go:build go1.21
+
+package gjson
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogValue(t *testing.T) {
+	json := `{"user":{"name":"Tom","age":37,"admin":true,"tags":["a","b"],
+		"id":123456789012345678901234,"score":9.5,"nick":null}}`
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	logger.Info("hello", "user", Get(json, "user"))
+	assert(t, strings.TrimSpace(buf.String()) == `{"level":"INFO",`+
+		`"msg":"hello","user":{"name":"Tom","age":37,"admin":true,`+
+		`"tags":["a","b"],"id":123456789012345678901234,"score":9.5,`+
+		`"nick":null}}`)
+
+	buf.Reset()
+	logger = slog.New(slog.NewTextHandler(&buf, nil))
+	logger.Info("hello", "user", Get(json, "user"))
+	out := buf.String()
+	assert(t, strings.Contains(out, `user.name=Tom user.age=37`))
+	assert(t, strings.Contains(out, `user.tags="[\"a\",\"b\"]"`))
+	assert(t, strings.Contains(out, `user.id=123456789012345678901234`))
+}
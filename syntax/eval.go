@@ -0,0 +1,582 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Eval searches json for a parsed path by walking its tree. Each component
+// is searched by the gjson package, while the tree decides how the
+// components are put together, such as which components apply to each
+// element after a '#'. The result is the same as gjson.Get(json, Format(p)),
+// with the CaseInsensitivePrefix option when the path has a folded key.
+func Eval(json string, p *Path) gjson.Result {
+	var e evaluator
+	return e.path(gjson.Parse(json), p)
+}
+
+// evaluator walks a tree, and records each step when it has a trace.
+type evaluator struct {
+	src   string // the json, for the offsets of a trace
+	trace *Trace
+	depth int // the depth of the steps in the trace
+	// dropped is set when the rest of the steps were dropped, after a query
+	// for all matches without a match
+	dropped bool
+}
+
+func (e *evaluator) path(cur gjson.Result, p *Path) gjson.Result {
+	if p.Lines {
+		cur = e.jsonLines(cur)
+	}
+	return e.steps(cur, p.Steps)
+}
+
+// jsonLines returns an array of the lines of JSON Lines, for the ".."
+// prefix.
+func (e *evaluator) jsonLines(cur gjson.Result) gjson.Result {
+	st := e.lines(cur)
+	b := []byte{'['}
+	gjson.ForEachLine(cur.Raw, func(line gjson.Result) bool {
+		if len(b) > 1 {
+			b = append(b, ',')
+		}
+		b = append(b, line.Raw...)
+		if st != nil {
+			st.Candidates++
+		}
+		return true
+	})
+	b = append(b, ']')
+	cur = gjson.Result{Type: gjson.JSON, Raw: string(b)}
+	e.end(st, cur)
+	return cur
+}
+
+// steps applies the steps as a path of their own, which is searched by gjson
+// apart from the path around it.
+func (e *evaluator) steps(cur gjson.Result, steps []Step) gjson.Result {
+	dropped := e.dropped
+	e.dropped = false
+	if linesPrefix(steps) {
+		cur, steps = e.jsonLines(cur), steps[2:]
+	}
+	res, _ := e.walk(cur, steps)
+	e.dropped = dropped
+	return res
+}
+
+// walk applies the steps, and also returns whether the last step was
+// reached, which is when it was applied to an object or an array, or it's a
+// modifier, a literal, or a multipath. The step after a reached step applies
+// even when the reached step returns nothing, if it's piped.
+func (e *evaluator) walk(cur gjson.Result, steps []Step) (gjson.Result, bool) {
+	reached := true
+	var keyed bool // the last step was a '#' or a query used as a key
+	var st *TraceStep
+	for i := 0; i < len(steps); i++ {
+		e.end(st, cur)
+		skip := i > 0 && !cur.Exists() && !(reached && (piped(steps, i) ||
+			(keyed && steps[i].Sep == Dot && dotPiper(steps[i]))))
+		if !skip && i > 0 && piped(steps, i) && linesPrefix(steps[i:]) {
+			// Like gjson, a path of its own, such as the path after a
+			// pipe, may start with the ".." prefix of JSON Lines, which is
+			// written as two empty keys.
+			return e.walk(e.jsonLines(cur), steps[i+2:])
+		}
+		st = e.begin(steps[i], cur)
+		if st != nil && i > 0 {
+			st.Pipe = piped(steps, i)
+		}
+		if skip {
+			e.skip(st)
+			return gjson.Result{}, false
+		}
+		reached = cur.IsObject() || cur.IsArray()
+		keyed = false
+		switch c := steps[i].Component.(type) {
+		case *Hash, *Query:
+			if cur.IsObject() && i+1 < len(steps) && !piped(steps, i+1) &&
+				!dotPiper(steps[i+1]) {
+				// a '#' or a query is a key of an object
+				var j int
+				cur, reached, j = e.members(st, cur, steps, i)
+				if e.dropped {
+					return cur, reached
+				}
+				st = nil
+				i = j - 1
+				continue
+			}
+			if st != nil {
+				if q, ok := c.(*Query); ok && cur.IsArray() {
+					e.matches(st, cur, q)
+				}
+			}
+			if i+1 < len(steps) && steps[i+1].Sep == Dot && cur.IsArray() {
+				elems := cur
+				if q, ok := c.(*Query); ok {
+					if !q.All {
+						return e.first(st, cur, q, steps[i:])
+					}
+					elems = get(cur, component(c))
+					if elems.Raw == "[]" {
+						// Like gjson, the path after the next pipe is only
+						// split off for a match, so without one the rest
+						// of the path is dropped.
+						e.end(st, elems)
+						e.dropped = true
+						return elems, true
+					}
+				}
+				// the components up to the next pipe apply to each element
+				n, ok := split(steps[i+1:])
+				if !ok {
+					return e.rest(st, cur, steps[i:])
+				}
+				j := i + 1 + n
+				e.end(st, elems)
+				st = nil
+				cur = e.each(elems, steps[i+1:j])
+				i = j - 1
+				continue
+			}
+			keyed = !cur.IsArray()
+		case *Key, *Index:
+			if cur.IsArray() && strings.ContainsAny(component(c), ".|") {
+				// Like gjson, a key of an array ends at the first '.' or
+				// '|', even when it's escaped, and the steps after it
+				// don't apply when it isn't found.
+				cur, _ = e.rest(st, cur, steps[i:])
+				return cur, cur.Exists()
+			}
+			if cur.IsObject() && i+1 < len(steps) && !piped(steps, i+1) {
+				var j int
+				cur, reached, j = e.members(st, cur, steps, i)
+				if e.dropped {
+					return cur, reached
+				}
+				st = nil
+				i = j - 1
+				continue
+			}
+		case *Modifier, *Literal:
+			res := get(cur, component(c))
+			if st != nil {
+				if _, ok := c.(*Modifier); ok {
+					st.ModifierInput, st.ModifierOutput = cur.Raw, res.Raw
+				}
+			}
+			cur, reached = res, true
+			continue
+		case *Multipath:
+			cur, reached = e.multipath(cur, c), true
+			continue
+		case *Descent:
+			// the components up to the next pipe apply to each member,
+			// unless a modifier or a multipath follows
+			j := i + 1
+			if j < len(steps) && !piped(steps, j) {
+				n, ok := split(steps[j:])
+				if !ok {
+					return e.rest(st, cur, steps[i:])
+				}
+				j += n
+			}
+			cur = e.descent(st, cur, c, steps[i+1:j])
+			e.end(st, cur)
+			st = nil
+			i = j - 1
+			continue
+		}
+		cur = get(cur, component(steps[i].Component))
+	}
+	e.end(st, cur)
+	return cur, reached
+}
+
+// linesPrefix reports whether the steps start with two empty keys and a
+// '.', which is the text of the ".." prefix.
+func linesPrefix(steps []Step) bool {
+	if len(steps) < 3 || steps[1].Sep != Dot || steps[2].Sep != Dot {
+		return false
+	}
+	for _, step := range steps[:2] {
+		if k, ok := step.Component.(*Key); !ok || k.Name != "" || k.Wild {
+			return false
+		}
+	}
+	return true
+}
+
+// members applies the steps after the key of step i to the members of an
+// object that match it, and returns the end of those steps. Like gjson, each
+// member that matches the key is tried until the components up to the next
+// pipe are found.
+func (e *evaluator) members(st *TraceStep, obj gjson.Result, steps []Step,
+	i int,
+) (gjson.Result, bool, int) {
+	key := steps[i].Component
+	j := chain(steps, i+1)
+	e.end(st, get(obj, component(key)))
+	res, reached := e.member(obj, key, steps[i+1:j])
+	return res, reached, j
+}
+
+// chain returns the end of the steps from i that aren't piped. The steps
+// after a '#', a query or a descent and a '.' run up to where gjson splits
+// off the rest of the path, as they apply to each of many values.
+func chain(steps []Step, i int) int {
+	for ; i < len(steps) && !piped(steps, i); i++ {
+		var each bool
+		switch steps[i].Component.(type) {
+		case *Hash, *Query:
+			each = i+1 < len(steps) && steps[i+1].Sep == Dot
+		case *Descent:
+			each = i+1 < len(steps) && !piped(steps, i+1)
+		}
+		if each {
+			if n, ok := split(steps[i+1:]); ok {
+				return i + 1 + n
+			}
+			return len(steps)
+		}
+	}
+	return i
+}
+
+// first applies the steps after a query for the first match to the match,
+// up to the first '|' of their text, as gjson splits it. The rest applies
+// to the result, even when it doesn't exist.
+func (e *evaluator) first(st *TraceStep, cur gjson.Result, q *Query,
+	steps []Step,
+) (gjson.Result, bool) {
+	n, ok := split(steps[1:])
+	if !ok {
+		return e.rest(st, cur, steps)
+	}
+	match := get(cur, component(q))
+	e.end(st, match)
+	if !match.Exists() {
+		e.skip(e.begin(steps[1], match))
+		return match, false
+	}
+	cur = e.steps(match, steps[1:1+n])
+	if 1+n == len(steps) {
+		return cur, true
+	}
+	return e.steps(cur, steps[1+n:]), true
+}
+
+// split returns the number of the steps that apply to each of many values,
+// which is up to the first '|' of their text, as gjson splits it. The text
+// isn't split when it starts with a multipath that isn't followed by the
+// '|', and ok is false when the '|' is inside of a component, such as a
+// multipath that doesn't come first.
+func split(steps []Step) (n int, ok bool) {
+	var b []byte
+	pipes := make(map[int]int) // the steps by the offsets of their '|'
+	for k, step := range steps {
+		_, descent := step.Component.(*Descent)
+		if k > 0 && !(step.Sep == Dot && descent) {
+			if step.Sep == Pipe {
+				pipes[len(b)] = k
+			}
+			b = append(b, byte(step.Sep))
+		}
+		b = appendComponent(b, step.Component)
+	}
+	i := pipeSplit(string(b))
+	if i < 0 {
+		return len(steps), true
+	}
+	n, ok = pipes[i]
+	return n, ok
+}
+
+// rest applies the steps with gjson as the text of a path, for the steps
+// that gjson splits inside of a component, such as the steps after a '#' or
+// a query with a '|' inside of a multipath.
+func (e *evaluator) rest(st *TraceStep, cur gjson.Result, steps []Step,
+) (gjson.Result, bool) {
+	text := appendPath(nil, &Path{Steps: append([]Step{{
+		Component: steps[0].Component}}, steps[1:]...)})
+	cur = get(cur, string(text))
+	e.end(st, cur)
+	return cur, true
+}
+
+// piped reports whether the step is piped, which is when it has the '|'
+// separator, or when the '.' separator comes after a modifier, a literal,
+// or a multipath, or before a modifier or a multipath that doesn't follow a
+// '#' or a query. A piped step applies even when the value before it doesn't
+// exist.
+func piped(steps []Step, i int) bool {
+	if steps[i].Sep == Pipe {
+		return true
+	}
+	switch steps[i-1].Component.(type) {
+	case *Modifier, *Literal, *Multipath:
+		return true
+	case *Hash, *Query:
+		return false
+	}
+	return dotPiper(steps[i])
+}
+
+// dotPiper reports whether the step is a modifier or a multipath, which
+// makes a '.' separator act like a '|'.
+func dotPiper(step Step) bool {
+	switch step.Component.(type) {
+	case *Modifier, *Multipath:
+		return true
+	}
+	return false
+}
+
+// member returns the steps applied to the first member of an object that
+// matches the key and for which the steps are found, and whether the last
+// step was reached for any member.
+func (e *evaluator) member(obj gjson.Result, key Component, steps []Step,
+) (gjson.Result, bool) {
+	text := component(key)
+	var res gjson.Result
+	var reached bool
+	e.depth++
+	obj.ForEach(func(k, v gjson.Result) bool {
+		// match a single member with gjson, so that wildcards and folding
+		// work the same
+		single := string(gjson.AppendJSONString([]byte{'{'}, k.Str)) + ":0}"
+		if !get(gjson.Parse(single), text).Exists() {
+			return true
+		}
+		if !v.IsObject() && !v.IsArray() {
+			// like gjson, the path doesn't go on into a scalar
+			return true
+		}
+		var ok bool
+		res, ok = e.walk(v, steps)
+		reached = reached || ok
+		return !res.Exists()
+	})
+	e.depth--
+	return res, reached
+}
+
+// descent returns an array of the steps applied to each member with the
+// key at any depth, leaving out those that don't exist. The members of an
+// object come before the members of the objects and arrays in it, like
+// gjson. The results of steps that apply to each of many values, such as
+// "#.name", are added one by one.
+func (e *evaluator) descent(st *TraceStep, cur gjson.Result, d *Descent,
+	steps []Step,
+) gjson.Result {
+	if !cur.IsObject() && !cur.IsArray() {
+		return gjson.Result{}
+	}
+	if st != nil {
+		st.Candidates = 0
+	}
+	text := component(d.Key)
+	var members []gjson.Result
+	var visit func(cur gjson.Result)
+	visit = func(cur gjson.Result) {
+		var children []gjson.Result
+		cur.ForEach(func(k, v gjson.Result) bool {
+			if st != nil {
+				st.Candidates++
+			}
+			single := string(gjson.AppendJSONString([]byte{'{'}, k.Str)) +
+				":0}"
+			if cur.IsObject() && get(gjson.Parse(single), text).Exists() {
+				members = append(members, v)
+			}
+			if v.IsObject() || v.IsArray() {
+				children = append(children, v)
+			}
+			return true
+		})
+		for _, child := range children {
+			visit(child)
+		}
+	}
+	// each top-level value is searched, like the lines of JSON Lines
+	gjson.ForEachLine(cur.Raw, func(v gjson.Result) bool {
+		if v.IsObject() || v.IsArray() {
+			visit(v)
+		}
+		return true
+	})
+	if st != nil {
+		st.Matches = len(members)
+	}
+	each := false
+	for i, step := range steps {
+		switch c := step.Component.(type) {
+		case *Hash:
+			each = each || (i+1 < len(steps) && steps[i+1].Sep == Dot)
+		case *Query:
+			each = each || c.All
+		case *Descent:
+			each = true
+		}
+	}
+	b := []byte{'['}
+	add := func(res gjson.Result) {
+		if len(b) > 1 {
+			b = append(b, ',')
+		}
+		b = appendRaw(b, res, "")
+	}
+	e.depth++
+	for _, member := range members {
+		if len(steps) == 0 {
+			add(member)
+			continue
+		}
+		res := e.steps(member, steps)
+		if each && res.IsArray() {
+			res.ForEach(func(_, elem gjson.Result) bool {
+				add(elem)
+				return true
+			})
+		} else if res.Exists() {
+			add(res)
+		}
+	}
+	e.depth--
+	b = append(b, ']')
+	return gjson.Result{Type: gjson.JSON, Raw: string(b)}
+}
+
+// each returns an array of the steps applied to each element of an array,
+// leaving out those that don't exist.
+func (e *evaluator) each(arr gjson.Result, steps []Step) gjson.Result {
+	b := []byte{'['}
+	e.depth++
+	arr.ForEach(func(_, elem gjson.Result) bool {
+		res := e.steps(elem, steps)
+		if res.Exists() {
+			if len(b) > 1 {
+				b = append(b, ',')
+			}
+			b = appendRaw(b, res, "")
+		}
+		return true
+	})
+	e.depth--
+	b = append(b, ']')
+	return gjson.Result{Type: gjson.JSON, Raw: string(b)}
+}
+
+func (e *evaluator) multipath(cur gjson.Result, m *Multipath) gjson.Result {
+	var b []byte
+	open, close := byte('['), byte(']')
+	if m.Object {
+		open, close = '{', '}'
+	}
+	b = append(b, open)
+	e.depth++
+	for _, sel := range m.Selectors {
+		res := e.path(cur, sel.Path)
+		if !res.Exists() {
+			continue
+		}
+		if len(b) > 1 {
+			b = append(b, ',')
+		}
+		if m.Object {
+			if sel.Name != "" && sel.Name[0] == '"' && gjson.Valid(sel.Name) {
+				b = append(b, sel.Name...)
+			} else if sel.Name != "" {
+				b = gjson.AppendJSONString(b, sel.Name)
+			} else {
+				b = gjson.AppendJSONString(b,
+					simpleName(nameOfLast(string(appendPath(nil, sel.Path)))))
+			}
+			b = append(b, ':')
+		}
+		b = appendRaw(b, res, "null")
+	}
+	e.depth--
+	b = append(b, close)
+	return gjson.Result{Type: gjson.JSON, Raw: string(b)}
+}
+
+// appendRaw appends the json of a result, or its string when it has no
+// json, or def when it has neither.
+func appendRaw(dst []byte, res gjson.Result, def string) []byte {
+	raw := res.Raw
+	if raw == "" {
+		raw = res.String()
+		if raw == "" {
+			raw = def
+		}
+	}
+	return append(dst, raw...)
+}
+
+// nameOfLast returns the text after the last separator of a path, like
+// gjson, which may be inside of the last component, such as "age" of
+// "!1|age".
+func nameOfLast(path string) string {
+	for i := len(path) - 1; i >= 0; i-- {
+		if (path[i] == '|' || path[i] == '.') && (i == 0 || path[i-1] != '\\') {
+			return path[i+1:]
+		}
+	}
+	return path
+}
+
+// simpleName returns the name of a multipath selector that has no name,
+// which is the text after the last separator, or "_" when that's not
+// simple.
+func simpleName(text string) string {
+	for i := 0; i < len(text); i++ {
+		if text[i] < ' ' {
+			return "_"
+		}
+		switch text[i] {
+		case '[', ']', '{', '}', '(', ')', '#', '|', '!':
+			return "_"
+		}
+	}
+	return text
+}
+
+// prefixOptions make the '~' prefix of a folded key case-insensitive.
+var prefixOptions = &gjson.Options{CaseInsensitivePrefix: true}
+
+// get searches a value for the text of components, like Result.Get. Keys
+// are written with the '~' prefix only when they're folded, and '~' is
+// otherwise escaped or in a query value or a modifier argument, where the
+// option doesn't change its meaning.
+func get(cur gjson.Result, text string) gjson.Result {
+	if strings.IndexByte(text, '~') < 0 {
+		return cur.Get(text)
+	}
+	res, _ := gjson.GetWithOptions(cur.Raw, text, prefixOptions)
+	// the offsets are in the json of cur, like those of Result.Get
+	for i := range res.Indexes {
+		if res.Indexes[i] != 0 {
+			res.Indexes[i] += cur.Index
+		}
+	}
+	if res.Raw != "" && res.Index+len(res.Raw) <= len(cur.Raw) &&
+		cur.Raw[res.Index:res.Index+len(res.Raw)] == res.Raw {
+		res.Index += cur.Index
+	} else {
+		res.Index = 0
+	}
+	return res
+}
+
+// component returns the text of a component on its own.
+func component(c Component) string {
+	return string(appendComponent(nil, c))
+}
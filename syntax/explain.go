@@ -0,0 +1,255 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Trace is a record of each step of searching json for a path, from
+// Explain. It's written as text by String, and as JSON by encoding/json.
+type Trace struct {
+	Path string `json:"path"`
+	// Error is the syntax error of the path, if any.
+	Error string `json:"error,omitempty"`
+	// Steps are the components in the order that they were applied.
+	Steps []*TraceStep `json:"steps"`
+	// Result is the result of the path, which is the same as gjson.Get.
+	Result gjson.Result `json:"result"`
+}
+
+// TraceStep is a component applied to a value.
+type TraceStep struct {
+	// Depth is zero for the components of the path, and one more for the
+	// components applied to each element after a '#', to each member that
+	// matches a key, or by a multipath.
+	Depth int `json:"depth"`
+	// Component is the text of the component, and Start and End are its
+	// span in the path.
+	Component  string `json:"component"`
+	Start, End int    `json:"-"`
+	// Kind is "key", "index", "hash", "query", "modifier", "literal",
+	// "multipath", "descent", or "lines" for the ".." prefix.
+	Kind string `json:"kind"`
+	// Pipe reports whether the component is at a pipe boundary, where it
+	// applies to the result so far, even when that result doesn't exist.
+	Pipe bool `json:"pipe,omitempty"`
+	// Input is the type of the value that the component is applied to, which
+	// is "null", "boolean", "number", "string", "object", "array", or
+	// "missing". InputOffset is its offset in the json, or -1 when it's
+	// computed, such as the result of a modifier.
+	Input       string `json:"input"`
+	InputOffset int    `json:"inputOffset"`
+	// Candidates is the number of members or elements of the input, or of
+	// lines for the ".." prefix, or of the members and elements at any depth
+	// for a recursive descent.
+	Candidates int `json:"candidates"`
+	// Matches is the number of members that match a key, or of elements
+	// that match a query, and Rejections is the number of elements that
+	// don't match a query.
+	Matches    int `json:"matches"`
+	Rejections int `json:"rejections"`
+	// ModifierInput and ModifierOutput are the json in and out of a
+	// modifier.
+	ModifierInput  string `json:"modifierInput,omitempty"`
+	ModifierOutput string `json:"modifierOutput,omitempty"`
+	// Output is the type of the result of the component, and OutputOffset
+	// is its offset in the json, like the input.
+	Output       string `json:"output"`
+	OutputOffset int    `json:"outputOffset"`
+	// Skipped reports whether the component wasn't applied, because its
+	// input doesn't exist and it's not at a pipe boundary.
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// Explain searches json for a path like Eval, and returns a record of each
+// step, for finding out why a path returns nothing.
+//
+//	fmt.Print(syntax.Explain(json, `friends.#(last=="Murphy").first`))
+func Explain(json, path string) Trace {
+	t := Trace{Path: path}
+	p, err := Parse(path)
+	if err != nil {
+		t.Error = err.Error()
+		return t
+	}
+	e := evaluator{src: json, trace: &t}
+	t.Result = e.path(gjson.Parse(json), p)
+	return t
+}
+
+// String returns the trace as text, with a line for each step.
+func (t Trace) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "path: %s\n", t.Path)
+	if t.Error != "" {
+		fmt.Fprintf(&sb, "error: %s\n", t.Error)
+		return sb.String()
+	}
+	for _, st := range t.Steps {
+		sb.WriteString(strings.Repeat("  ", st.Depth))
+		if st.Pipe {
+			sb.WriteString("| ")
+		}
+		fmt.Fprintf(&sb, "%s (%s) %s", st.Component, st.Kind,
+			typeAt(st.Input, st.InputOffset))
+		if st.Skipped {
+			sb.WriteString(" skipped\n")
+			continue
+		}
+		fmt.Fprintf(&sb, " -> %s", typeAt(st.Output, st.OutputOffset))
+		if st.Candidates > 0 {
+			fmt.Fprintf(&sb, " candidates=%d", st.Candidates)
+		}
+		if st.Matches > 0 || st.Rejections > 0 || st.Kind == "query" {
+			fmt.Fprintf(&sb, " matches=%d", st.Matches)
+		}
+		if st.Rejections > 0 {
+			fmt.Fprintf(&sb, " rejections=%d", st.Rejections)
+		}
+		if st.Kind == "modifier" {
+			fmt.Fprintf(&sb, " in=%s out=%s", shorten(st.ModifierInput),
+				shorten(st.ModifierOutput))
+		}
+		sb.WriteByte('\n')
+	}
+	if t.Result.Exists() {
+		fmt.Fprintf(&sb, "result: %s\n", shorten(t.Result.Raw))
+	} else {
+		sb.WriteString("result: missing\n")
+	}
+	return sb.String()
+}
+
+func typeAt(typ string, offset int) string {
+	if offset < 0 {
+		return typ
+	}
+	return fmt.Sprintf("%s@%d", typ, offset)
+}
+
+// shorten returns the quoted json, cut short when it's long.
+func shorten(json string) string {
+	const max = 40
+	if len(json) > max {
+		json = json[:max] + "..."
+	}
+	return fmt.Sprintf("%q", json)
+}
+
+// begin adds a step to the trace, or returns nil when there's no trace.
+func (e *evaluator) begin(step Step, cur gjson.Result) *TraceStep {
+	if e.trace == nil {
+		return nil
+	}
+	st := &TraceStep{Depth: e.depth, Component: component(step.Component),
+		Kind: kind(step.Component), Input: typeName(cur),
+		InputOffset: e.offset(cur)}
+	st.Start, st.End = step.Component.Span()
+	if cur.IsObject() || cur.IsArray() {
+		cur.ForEach(func(k, _ gjson.Result) bool {
+			st.Candidates++
+			if cur.IsObject() && (st.Kind == "key" || st.Kind == "index") {
+				// match a single member with gjson, like member does
+				single := string(gjson.AppendJSONString([]byte{'{'},
+					k.Str)) + ":0}"
+				if get(gjson.Parse(single), st.Component).Exists() {
+					st.Matches++
+				}
+			}
+			return true
+		})
+	}
+	e.trace.Steps = append(e.trace.Steps, st)
+	return st
+}
+
+// lines adds the step of the ".." prefix to the trace.
+func (e *evaluator) lines(cur gjson.Result) *TraceStep {
+	if e.trace == nil {
+		return nil
+	}
+	st := &TraceStep{Depth: e.depth, Component: "..", End: 2, Kind: "lines",
+		Input: typeName(cur), InputOffset: e.offset(cur)}
+	e.trace.Steps = append(e.trace.Steps, st)
+	return st
+}
+
+// end records the output of a step.
+func (e *evaluator) end(st *TraceStep, cur gjson.Result) {
+	if st != nil && !st.Skipped {
+		st.Output, st.OutputOffset = typeName(cur), e.offset(cur)
+	}
+}
+
+// skip records that a step wasn't applied.
+func (e *evaluator) skip(st *TraceStep) {
+	if st != nil {
+		st.Skipped = true
+		st.Output, st.OutputOffset = "missing", -1
+	}
+}
+
+// matches records the elements of an array that match a query.
+func (e *evaluator) matches(st *TraceStep, arr gjson.Result, q *Query) {
+	all := *q
+	all.All = true
+	st.Matches = len(get(arr, component(&all)).Array())
+	st.Rejections = st.Candidates - st.Matches
+}
+
+// offset returns the offset of a result in the json, or -1 when the result
+// isn't found there.
+func (e *evaluator) offset(res gjson.Result) int {
+	i, raw := res.Index, res.Raw
+	if raw == "" || i < 0 || i+len(raw) > len(e.src) || e.src[i:i+len(raw)] !=
+		raw {
+		return -1
+	}
+	return i
+}
+
+func typeName(res gjson.Result) string {
+	switch res.Type {
+	case gjson.Null:
+		if !res.Exists() {
+			return "missing"
+		}
+		return "null"
+	case gjson.False, gjson.True:
+		return "boolean"
+	case gjson.Number:
+		return "number"
+	case gjson.String:
+		return "string"
+	}
+	if res.IsArray() {
+		return "array"
+	}
+	return "object"
+}
+
+func kind(c Component) string {
+	switch c.(type) {
+	case *Key:
+		return "key"
+	case *Index:
+		return "index"
+	case *Hash:
+		return "hash"
+	case *Query:
+		return "query"
+	case *Modifier:
+		return "modifier"
+	case *Literal:
+		return "literal"
+	case *Descent:
+		return "descent"
+	}
+	return "multipath"
+}
@@ -0,0 +1,107 @@
+package syntax
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestExplainResult(t *testing.T) {
+	docs := []string{testJSON, testLines, ``, `"x"`, `[[1,2],[3,4]]`,
+		`{"m":{"t":1}}`, `{"name":[{"first":1}],"a":{"first":2}}`}
+	for _, path := range append(testPaths, genPaths(2000)...) {
+		for _, json := range docs {
+			exp := gjson.Get(json, path)
+			res := Explain(json, path).Result
+			if res.Raw != exp.Raw || res.Type != exp.Type {
+				t.Fatalf("%q: %q: expected %q, got %q", json, path, exp.Raw,
+					res.Raw)
+			}
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	tr := Explain(testJSON, `friends.#(last=="Murphy")#.first|@reverse`)
+	assert(t, len(tr.Steps) == 5)
+	st := tr.Steps[0]
+	assert(t, st.Component == "friends" && st.Kind == "key" && st.Depth == 0)
+	assert(t, st.Input == "object" && st.InputOffset == 0)
+	assert(t, st.Candidates == 12 && st.Matches == 1)
+	assert(t, st.Output == "array" &&
+		strings.HasPrefix(testJSON[st.OutputOffset:], "[\n    {"))
+	st = tr.Steps[1]
+	assert(t, st.Depth == 1 && st.Kind == "query" && st.Candidates == 3 &&
+		st.Matches == 2 && st.Rejections == 1 && st.Start == 8 && st.End == 26)
+	st = tr.Steps[2]
+	assert(t, st.Depth == 2 && st.Input == "object" && st.Output == "string")
+	assert(t, testJSON[st.OutputOffset:st.OutputOffset+6] == `"Dale"`)
+	assert(t, tr.Steps[3].Depth == 2)
+	st = tr.Steps[4]
+	assert(t, st.Depth == 0 && st.Pipe && st.Kind == "modifier" &&
+		st.InputOffset == -1)
+	assert(t, st.ModifierInput == `["Dale","Jane"]` &&
+		st.ModifierOutput == `["Jane","Dale"]`)
+	assert(t, tr.Result.Raw == `["Jane","Dale"]`)
+
+	// the component after a missing value is skipped
+	tr = Explain(testJSON, `friends.#(last=="Nope").first`)
+	assert(t, tr.Steps[1].Output == "missing" && tr.Steps[1].Rejections == 3)
+	assert(t, tr.Steps[2].Skipped && !tr.Result.Exists())
+	text := tr.String()
+	assert(t, strings.Contains(text, `#(last=="Nope") (query) array@`))
+	assert(t, strings.Contains(text, "matches=0 rejections=3\n"))
+	assert(t, strings.Contains(text, "first (key) missing skipped\n"))
+	assert(t, strings.HasSuffix(text, "result: missing\n"))
+
+	// each member that matches is tried
+	tr = Explain(testJSON, "name.middle")
+	assert(t, len(tr.Steps) == 3 && tr.Steps[0].Matches == 2)
+	assert(t, tr.Steps[1].Output == "missing" && tr.Steps[2].Output == "string")
+
+	tr = Explain(testLines, "..#.a")
+	assert(t, tr.Steps[0].Kind == "lines" && tr.Steps[0].Candidates == 3)
+	assert(t, tr.Steps[1].Kind == "hash" && len(tr.Steps) == 5)
+
+	tr = Explain(testJSON, "..friends.#(age>45)#.first")
+	st = tr.Steps[0]
+	assert(t, st.Kind == "descent" && st.Matches == 1 && st.Output == "array")
+	assert(t, tr.Steps[1].Depth == 1 && tr.Steps[2].Depth == 2)
+	assert(t, tr.Result.Raw == `["Roger","Jane"]`)
+
+	// the components after a '#' are traced for each element
+	tr = Explain(testJSON, "q.#.@reverse.0")
+	assert(t, len(tr.Steps) == 6 && tr.Steps[1].Kind == "hash")
+	st = tr.Steps[2]
+	assert(t, st.Kind == "modifier" && st.Depth == 2 &&
+		st.ModifierInput == "[1,2]" && st.ModifierOutput == "[2,1]")
+	assert(t, tr.Steps[3].Depth == 2 && tr.Steps[3].Output == "number")
+	assert(t, tr.Steps[4].ModifierInput == "[3,4]")
+	assert(t, tr.Result.Raw == "[2,4]")
+	tr = Explain(testJSON, "m.#.!1")
+	assert(t, len(tr.Steps) == 4 && tr.Steps[2].Kind == "literal" &&
+		tr.Steps[2].Depth == 2 && tr.Steps[2].Input == "object")
+	assert(t, tr.Result.Raw == "[1,1]")
+
+	// a literal after a modifier or a descent and a '.'
+	tr = Explain(testJSON, "name.@flatten.!true")
+	assert(t, len(tr.Steps) == 3 && tr.Steps[2].Kind == "literal" &&
+		tr.Steps[2].Pipe)
+	assert(t, tr.Result.Raw == "true")
+	tr = Explain(testJSON, "..first.!true")
+	assert(t, len(tr.Steps) == 5 && tr.Steps[1].Kind == "literal" &&
+		tr.Steps[1].Depth == 1)
+	assert(t, tr.Result.Raw == "[true,true,true,true]")
+
+	tr = Explain(testJSON, "a.#(")
+	assert(t, tr.Error != "" && len(tr.Steps) == 0)
+	assert(t, strings.HasPrefix(tr.String(), "path: a.#(\nerror: syntax: "))
+
+	b, err := json.Marshal(Explain(testJSON, "name.first"))
+	assert(t, err == nil)
+	assert(t, gjson.GetBytes(b, "steps.1.component").String() == "first")
+	assert(t, gjson.GetBytes(b, "steps.1.depth").Int() == 1)
+	assert(t, gjson.GetBytes(b, "result").String() == "Tom")
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package syntax
+
+import "strconv"
+
+// Format returns the canonical text of a path. Keys are escaped only where
+// needed, queries have no spaces and use the "#(...)" brackets, and the "="
+// operator is written as "==". Parsing the text returns the same tree,
+// except for the spans.
+func Format(p *Path) string {
+	return string(appendPath(nil, p))
+}
+
+func appendPath(dst []byte, p *Path) []byte {
+	if p.Lines {
+		dst = append(dst, '.', '.')
+	}
+	for _, step := range p.Steps {
+		_, descent := step.Component.(*Descent)
+		if step.Sep != NoSep && !(step.Sep == Dot && descent) {
+			dst = append(dst, byte(step.Sep))
+		}
+		dst = appendComponent(dst, step.Component)
+	}
+	return dst
+}
+
+func appendComponent(dst []byte, comp Component) []byte {
+	switch c := comp.(type) {
+	case *Key:
+		if c.Fold {
+			dst = append(dst, '~')
+		}
+		return appendKey(dst, c.Name, c.Wild)
+	case *Index:
+		return strconv.AppendInt(dst, int64(c.N), 10)
+	case *Descent:
+		dst = append(dst, '.', '.')
+		key := appendComponent(nil, c.Key)
+		if len(key) > 0 && key[0] >= '0' && key[0] <= '9' {
+			// "..0" is JSON Lines
+			dst = append(dst, '\\')
+		}
+		return append(dst, key...)
+	case *Hash:
+		return append(dst, '#')
+	case *Query:
+		dst = append(dst, '#', '(')
+		if c.Path != nil {
+			dst = appendPath(dst, c.Path)
+		}
+		dst = append(dst, c.Op...)
+		dst = append(dst, c.Value...)
+		dst = append(dst, ')')
+		if c.All {
+			dst = append(dst, '#')
+		}
+		return dst
+	case *Modifier:
+		dst = append(dst, '@')
+		dst = append(dst, c.Name...)
+		if c.HasArg {
+			dst = append(dst, ':')
+			dst = append(dst, c.Arg...)
+		}
+		return dst
+	case *Literal:
+		dst = append(dst, '!')
+		return append(dst, c.Value...)
+	case *Multipath:
+		open, close := byte('['), byte(']')
+		if c.Object {
+			open, close = '{', '}'
+		}
+		dst = append(dst, open)
+		for i, sel := range c.Selectors {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			if sel.Name != "" {
+				dst = append(dst, sel.Name...)
+				dst = append(dst, ':')
+			}
+			dst = appendPath(dst, sel.Path)
+		}
+		return append(dst, close)
+	}
+	return dst
+}
+
+// appendKey appends the escaped key. A key that's an index is escaped too,
+// so that it stays a key.
+func appendKey(dst []byte, name string, wild bool) []byte {
+	if _, ok := parseIndex(name); ok {
+		dst = append(dst, '\\')
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !isSafeKeyChar(c) && !(wild && (c == '*' || c == '?')) {
+			dst = append(dst, '\\')
+		}
+		dst = append(dst, c)
+	}
+	return dst
+}
+
+// isSafeKeyChar is like the one used by gjson.Escape, but ':' is escaped too,
+// as it separates the name of a multipath selector.
+func isSafeKeyChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+		(c >= '0' && c <= '9') || c <= ' ' || c > '~' || c == '_' ||
+		c == '-'
+}
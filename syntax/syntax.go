@@ -0,0 +1,807 @@
+// Copyright 2024 Joshua J Baker. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package syntax parses GJSON paths into a syntax tree.
+//
+// The tree describes a path the way the gjson package reads it, with the
+// byte span of each node in the path text, so that tools can highlight,
+// complete, and lint paths without reimplementing the syntax.
+//
+//	p, err := syntax.Parse(`friends.#(last=="Murphy")#.first`)
+//	if err != nil {
+//		// err is a *syntax.Error with the offset of the problem
+//	}
+//	syntax.Inspect(p, func(n syntax.Node) bool {
+//		start, end := n.Span()
+//		...
+//		return true
+//	})
+package syntax
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Error is a syntax error in a path.
+type Error struct {
+	// Pos is the byte offset of the error in the path.
+	Pos int
+	// Msg describes the error.
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("syntax: %s at offset %d", e.Msg, e.Pos)
+}
+
+// Node is a node of the syntax tree: a *Path, a *Selector, or a Component.
+type Node interface {
+	// Span returns the byte offsets of the node in the path, from start up
+	// to, but not including, end.
+	Span() (start, end int)
+}
+
+// Sep is the separator before a component.
+type Sep byte
+
+const (
+	// NoSep is the separator of the first component of a path.
+	NoSep Sep = 0
+	// Dot is the '.' separator.
+	Dot Sep = '.'
+	// Pipe is the '|' separator.
+	Pipe Sep = '|'
+)
+
+// Path is a path, or the nested path of a query or a multipath.
+type Path struct {
+	Start, End int
+	// Lines is true for a path that starts with "..", which searches the
+	// json as JSON Lines, unless it's a recursive descent.
+	Lines bool
+	// Steps are the components of the path, with the separator before each.
+	// There's always at least one step.
+	Steps []Step
+}
+
+// Step is a component of a path and the separator before it.
+type Step struct {
+	Sep       Sep
+	Component Component
+}
+
+// Component is a *Key, *Index, *Hash, *Query, *Modifier, *Literal,
+// *Multipath, or *Descent.
+type Component interface {
+	Node
+	component()
+}
+
+// Key is an object key, such as "name", "fav\.movie", or "child*". It's also
+// the index of an array element when it's an unsigned integer.
+type Key struct {
+	Start, End int
+	// Name is the key without escape characters.
+	Name string
+	// Wild is true when Name has the '*' or '?' wildcards.
+	Wild bool
+	// Fold is true for a key that matches without regard to case, which is
+	// written with the '~' prefix. See CaseInsensitivePrefix.
+	Fold bool
+}
+
+// Index is an array index, such as "0". On an object it's a key.
+type Index struct {
+	Start, End int
+	N          int
+}
+
+// Hash is the '#' component, which is the number of elements of an array,
+// or, when followed by a '.', each element of the array.
+type Hash struct {
+	Start, End int
+}
+
+// Query is an array query, such as `#(last=="Murphy")` or `#(age>45)#`.
+type Query struct {
+	Start, End int
+	// Path is the path of the value that's compared, or nil for the element
+	// itself.
+	Path *Path
+	// Op is the operator, which is one of "==", "!=", "<", "<=", ">", ">=",
+	// "%", and "!%", or empty for a query that checks that Path exists.
+	Op string
+	// Value is the json text that's compared, such as `"Murphy"` or `45`.
+	Value                string
+	ValueStart, ValueEnd int
+	// All is true for a query that returns all matches.
+	All bool
+}
+
+// Modifier is a modifier, such as "@reverse" or `@pretty:{"indent":"  "}`.
+type Modifier struct {
+	Start, End int
+	// Name is the name of the modifier, without the '@'.
+	Name string
+	// Arg is the argument, which is json or plain text.
+	Arg              string
+	ArgStart, ArgEnd int
+	HasArg           bool
+}
+
+// Literal is a json value, such as "!true" or `!{"a":1}`. A string or a
+// number literal takes the rest of the path.
+type Literal struct {
+	Start, End int
+	// Value is the json text of the literal, without the '!'.
+	Value string
+}
+
+// Multipath is a new array or object from other paths, such as
+// "[name,age]" or `{name,"years":age}`.
+type Multipath struct {
+	Start, End int
+	// Object is true for an object, which uses the '{' and '}' brackets.
+	Object    bool
+	Selectors []*Selector
+}
+
+// Descent is a recursive descent, such as "..name", which is each member
+// with the key at any depth. The steps after it, up to the next pipe, apply
+// to each member. Its span includes the '.' separator before it, so that the
+// text is always "..name".
+type Descent struct {
+	Start, End int
+	Key        *Key
+}
+
+// Selector is a path of a multipath, with an optional name.
+type Selector struct {
+	Start, End int
+	// Name is the name as it's written, which may be a json string, or is
+	// empty when the selector has no name.
+	Name string
+	Path *Path
+}
+
+func (n *Path) Span() (start, end int)      { return n.Start, n.End }
+func (n *Selector) Span() (start, end int)  { return n.Start, n.End }
+func (n *Key) Span() (start, end int)       { return n.Start, n.End }
+func (n *Index) Span() (start, end int)     { return n.Start, n.End }
+func (n *Hash) Span() (start, end int)      { return n.Start, n.End }
+func (n *Query) Span() (start, end int)     { return n.Start, n.End }
+func (n *Modifier) Span() (start, end int)  { return n.Start, n.End }
+func (n *Literal) Span() (start, end int)   { return n.Start, n.End }
+func (n *Multipath) Span() (start, end int) { return n.Start, n.End }
+func (n *Descent) Span() (start, end int)   { return n.Start, n.End }
+
+func (*Key) component()       {}
+func (*Index) component()     {}
+func (*Hash) component()      {}
+func (*Query) component()     {}
+func (*Modifier) component()  {}
+func (*Literal) component()   {}
+func (*Multipath) component() {}
+func (*Descent) component()   {}
+
+// Inspect calls f for each node of the tree, depth first, starting with n.
+// When f returns false, the children of the node are skipped.
+func Inspect(n Node, f func(Node) bool) {
+	if n == nil || !f(n) {
+		return
+	}
+	switch n := n.(type) {
+	case *Path:
+		for _, step := range n.Steps {
+			Inspect(step.Component, f)
+		}
+	case *Query:
+		if n.Path != nil {
+			Inspect(n.Path, f)
+		}
+	case *Multipath:
+		for _, sel := range n.Selectors {
+			Inspect(sel, f)
+		}
+	case *Selector:
+		Inspect(n.Path, f)
+	case *Descent:
+		Inspect(n.Key, f)
+	}
+}
+
+// Equal reports whether two trees are the same, without regard to spans.
+func Equal(a, b *Path) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Lines != b.Lines || len(a.Steps) != len(b.Steps) {
+		return false
+	}
+	for i, step := range a.Steps {
+		if step.Sep != b.Steps[i].Sep ||
+			!equalComponent(step.Component, b.Steps[i].Component) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalComponent(a, b Component) bool {
+	switch a := a.(type) {
+	case *Key:
+		b, ok := b.(*Key)
+		return ok && a.Name == b.Name && a.Wild == b.Wild && a.Fold == b.Fold
+	case *Index:
+		b, ok := b.(*Index)
+		return ok && a.N == b.N
+	case *Hash:
+		_, ok := b.(*Hash)
+		return ok
+	case *Query:
+		b, ok := b.(*Query)
+		return ok && a.Op == b.Op && a.Value == b.Value && a.All == b.All &&
+			Equal(a.Path, b.Path)
+	case *Modifier:
+		b, ok := b.(*Modifier)
+		return ok && a.Name == b.Name && a.Arg == b.Arg &&
+			a.HasArg == b.HasArg
+	case *Literal:
+		b, ok := b.(*Literal)
+		return ok && a.Value == b.Value
+	case *Multipath:
+		b, ok := b.(*Multipath)
+		if !ok || a.Object != b.Object ||
+			len(a.Selectors) != len(b.Selectors) {
+			return false
+		}
+		for i, sel := range a.Selectors {
+			if sel.Name != b.Selectors[i].Name ||
+				!Equal(sel.Path, b.Selectors[i].Path) {
+				return false
+			}
+		}
+		return true
+	case *Descent:
+		b, ok := b.(*Descent)
+		return ok && equalComponent(a.Key, b.Key)
+	}
+	return false
+}
+
+// A Mode changes how a path is parsed.
+type Mode uint
+
+const (
+	// CaseInsensitivePrefix parses a key with a '~' prefix, such as
+	// "~userid", as a key that matches without regard to case, like a
+	// gjson.Engine with CaseInsensitivePrefix. Otherwise the '~' is part of
+	// the key, as it is for gjson.Get.
+	CaseInsensitivePrefix Mode = 1 << iota
+)
+
+// Parse parses a path the way gjson.Get reads it.
+func Parse(path string) (*Path, error) {
+	return ParseMode(path, 0)
+}
+
+// ParseMode parses a path with a mode.
+func ParseMode(path string, mode Mode) (*Path, error) {
+	p := &parser{src: path, mode: mode}
+	return p.path(0, len(path))
+}
+
+// MustParse is like Parse but panics when the path has an error.
+func MustParse(path string) *Path {
+	p, err := Parse(path)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+type parser struct {
+	src  string
+	mode Mode
+}
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// path parses src[start:end].
+func (p *parser) path(start, end int) (*Path, error) {
+	n := &Path{Start: start, End: end}
+	i := start
+	if end-i >= 2 && p.src[i] == '.' && p.src[i+1] == '.' &&
+		!isDescent(p.src[i:end]) {
+		n.Lines = true
+		i += 2
+		if isDescent(p.src[i:end]) {
+			return nil, p.errorf(i, "recursive descent after \"..\"")
+		}
+	}
+	sep := NoSep
+	lead, each := true, false
+	for {
+		comp, j, err := p.component(i, end, lead, each)
+		if err != nil {
+			return nil, err
+		}
+		n.Steps = append(n.Steps, Step{sep, comp})
+		if j == end {
+			return n, nil
+		}
+		switch p.src[j] {
+		case '.':
+			sep = Dot
+		case '|':
+			sep = Pipe
+		default:
+			return nil, p.errorf(j, "expected '.' or '|'")
+		}
+		// the components after a '#', a query or a descent and a '.' are a
+		// path of their own, which may start with a literal, and a '.' after
+		// a modifier, a literal or a multipath acts like a '|'
+		switch comp.(type) {
+		case *Hash, *Query, *Descent:
+			lead, each = true, sep == Dot
+		case *Modifier, *Literal, *Multipath:
+			lead, each = true, false
+		default:
+			lead, each = sep != Dot, false
+		}
+		i = j + 1
+		if sep == Dot && isDescent(p.src[j:end]) {
+			// the '.' is also the start of the descent
+			i = j
+		}
+	}
+}
+
+// component parses the component at src[i:end], and returns the position
+// after it. A lead component may be a literal, which ends at the '|' where
+// gjson splits off the rest of the path when it's the first component
+// applied to each element.
+func (p *parser) component(i, end int, lead, each bool,
+) (Component, int, error) {
+	if isDescent(p.src[i:end]) {
+		return p.descent(i, end)
+	}
+	if i < end {
+		// A modifier or a multipath may follow any separator, while a literal
+		// must be the first component, follow a pipe, or follow a dot after
+		// a '#', a query, a descent, a modifier, a literal or a multipath.
+		switch p.src[i] {
+		case '@':
+			return p.modifier(i, end)
+		case '[', '{':
+			return p.multipath(i, end)
+		case '!':
+			if lead {
+				lend := end
+				if each {
+					if k := pipeSplit(p.src[i:end]); k >= 0 {
+						lend = i + k
+					}
+				}
+				if lit, j, ok := p.literal(i, lend); ok {
+					return lit, j, nil
+				}
+			}
+		case '#':
+			if i+1 < end && (p.src[i+1] == '(' || p.src[i+1] == '[') {
+				return p.query(i, end)
+			}
+			if i+1 == end || p.src[i+1] == '.' || p.src[i+1] == '|' {
+				return &Hash{Start: i, End: i + 1}, i + 1, nil
+			}
+		}
+	}
+	return p.key(i, end)
+}
+
+func (p *parser) key(i, end int) (Component, int, error) {
+	start := i
+	var fold, esc, wild bool
+	if end-i > 1 && p.src[i] == '~' && p.mode&CaseInsensitivePrefix != 0 {
+		fold = true
+		i++
+	}
+	var name []byte
+	s := i
+	for ; i < end; i++ {
+		c := p.src[i]
+		if c == '.' || c == '|' {
+			break
+		}
+		if c == '*' || c == '?' {
+			wild = true
+		} else if c == '\\' {
+			if !esc {
+				esc = true
+				name = append(name, p.src[s:i]...)
+			}
+			i++
+			if i < end {
+				name = append(name, p.src[i])
+			}
+			continue
+		}
+		if esc {
+			name = append(name, c)
+		}
+	}
+	key := &Key{Start: start, End: i, Name: p.src[s:i], Wild: wild,
+		Fold: fold}
+	if esc {
+		key.Name = string(name)
+	} else if !fold {
+		if n, ok := parseIndex(key.Name); ok {
+			return &Index{Start: start, End: i, N: n}, i, nil
+		}
+	}
+	return key, i, nil
+}
+
+func (p *parser) descent(i, end int) (Component, int, error) {
+	// the key doesn't start with a digit, so it's never an index
+	key, j, _ := p.key(i+2, end)
+	return &Descent{Start: i, End: j, Key: key.(*Key)}, j, nil
+}
+
+// isDescent reports whether s starts with a recursive descent, which is ".."
+// followed by the start of a key. Otherwise ".." at the start of a path is
+// JSON Lines.
+func isDescent(s string) bool {
+	if len(s) < 3 || s[0] != '.' || s[1] != '.' {
+		return false
+	}
+	switch c := s[2]; c {
+	case '.', '|', '#', '@', '[', '{', '!':
+		return false
+	default:
+		return c < '0' || c > '9'
+	}
+}
+
+// parseIndex parses the text of an index, which is an integer without a
+// sign or leading zeros.
+func parseIndex(s string) (int, bool) {
+	if s == "" || (s[0] == '0' && len(s) > 1) {
+		return 0, false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
+
+func (p *parser) query(i, end int) (Component, int, error) {
+	start := i
+	i += 2
+	op := -1 // start of the operator
+	depth := 1
+	for ; i < end; i++ {
+		c := p.src[i]
+		if depth == 1 && op == -1 {
+			switch c {
+			case '!', '=', '<', '>', '%':
+				op = i
+				continue
+			}
+		}
+		switch c {
+		case '\\':
+			i++
+		case '[', '(':
+			depth++
+		case ']', ')':
+			depth--
+		case '"':
+			i = skipString(p.src[:end], i)
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	if depth > 0 {
+		return nil, 0, p.errorf(start, "unterminated query")
+	}
+	q := &Query{Start: start}
+	pathEnd := i
+	if op != -1 {
+		pathEnd = op
+		j := op
+		var opsz int
+		switch {
+		case p.src[j] == '=' && j+1 < i && p.src[j+1] == '=':
+			j, opsz = j+1, 1
+		case p.src[j] != '=' && p.src[j] != '%' && j+1 < i &&
+			p.src[j+1] == '=':
+			opsz = 2
+		case p.src[j] == '!' && j+1 < i && p.src[j+1] == '%':
+			opsz = 2
+		case p.src[j] != '!':
+			opsz = 1
+		default:
+			return nil, 0, p.errorf(op, "invalid query operator")
+		}
+		q.Op = p.src[j : j+opsz]
+		if q.Op == "=" {
+			q.Op = "=="
+		}
+		q.ValueStart, q.ValueEnd = trimSpan(p.src, j+opsz, i)
+		q.Value = p.src[q.ValueStart:q.ValueEnd]
+	}
+	ps, pe := trimSpan(p.src, start+2, pathEnd)
+	if ps < pe {
+		var err error
+		if q.Path, err = p.path(ps, pe); err != nil {
+			return nil, 0, err
+		}
+	}
+	i++
+	if i < end && p.src[i] == '#' {
+		q.All = true
+		i++
+	}
+	q.End = i
+	return q, i, p.expectSep(i, end)
+}
+
+func (p *parser) modifier(i, end int) (Component, int, error) {
+	m := &Modifier{Start: i}
+	i++
+	s := i
+	for ; i < end; i++ {
+		c := p.src[i]
+		if c == ':' || c == '|' || c == '.' {
+			break
+		}
+	}
+	m.Name = p.src[s:i]
+	if i+1 == end && p.src[i] == ':' {
+		i++
+	} else if i < end && p.src[i] == ':' {
+		i++
+		m.HasArg = true
+		m.ArgStart = i
+		switch p.src[i] {
+		case '{', '[', '"':
+			if gjson.Parse(p.src[i:end]).Exists() {
+				i += len(squash(p.src[i:end]))
+				m.ArgEnd = i
+			}
+		}
+		if m.ArgEnd == 0 {
+			for ; i < end && p.src[i] != '|'; i++ {
+				switch p.src[i] {
+				case '{', '[', '"', '(':
+					i += len(squash(p.src[i:end])) - 1
+				}
+			}
+			m.ArgEnd = i
+		}
+		m.Arg = p.src[m.ArgStart:m.ArgEnd]
+	}
+	m.End = i
+	return m, i, p.expectSep(i, end)
+}
+
+// literal parses a literal, and returns false when the text isn't a literal
+// and is a key instead.
+func (p *parser) literal(i, end int) (Component, int, bool) {
+	lit := &Literal{Start: i}
+	s := i + 1
+	if s < end {
+		switch p.src[s] {
+		case '{', '[':
+			i = s + len(squash(p.src[s:end]))
+			lit.Value, lit.End = p.src[s:i], i
+			return lit, i, true
+		case '"', '+', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			lit.Value, lit.End = p.src[s:end], end
+			return lit, end, true
+		}
+	}
+	for i = s; i < end && p.src[i] != '.' && p.src[i] != '|'; i++ {
+	}
+	switch strings.ToLower(p.src[s:i]) {
+	case "true", "false", "null", "nan", "inf":
+		lit.Value, lit.End = p.src[s:i], i
+		return lit, i, true
+	}
+	return nil, 0, false
+}
+
+// pipeSplit returns the position of the '|' where gjson splits the path
+// after a '#', a query, or a recursive descent, or -1 when it isn't split.
+func pipeSplit(path string) int {
+	if strings.IndexByte(path, '|') < 0 {
+		return -1
+	}
+	if path[0] == '{' {
+		// Like gjson, the value after the '{' is squashed as if it started
+		// with a bracket, which is the whole multipath only when it doesn't.
+		rest := path[1:]
+		switch rest[0] {
+		case '{', '[', '(', '"':
+		default:
+			rest = "(" + rest[1:]
+		}
+		i := 1 + len(squash(rest))
+		if i < len(path) && path[i] == '|' {
+			return i
+		}
+		return -1
+	}
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			i++
+		case '|':
+			return i
+		case '.':
+			if i == len(path)-1 {
+				return -1
+			}
+			if path[i+1] != '#' {
+				continue
+			}
+			i += 2
+			if i == len(path) {
+				return -1
+			}
+			if path[i] != '[' && path[i] != '(' {
+				// like gjson, the character after ".#" is skipped, even
+				// when it's a '|'
+				continue
+			}
+			// balance the brackets of a selector
+			open, close := path[i], byte(']')
+			if open == '(' {
+				close = ')'
+			}
+			depth := 1
+			for i++; i < len(path); i++ {
+				if path[i] == '\\' {
+					i++
+				} else if path[i] == open {
+					depth++
+				} else if path[i] == close {
+					depth--
+					if depth == 0 {
+						break
+					}
+				} else if path[i] == '"' {
+					i = skipString(path, i)
+				}
+			}
+		}
+	}
+	return -1
+}
+
+func (p *parser) multipath(i, end int) (Component, int, error) {
+	m := &Multipath{Start: i, Object: p.src[i] == '{'}
+	modifier := false
+	depth := 1
+	colon := -1
+	s := i + 1
+	push := func(i int) error {
+		sel := &Selector{Start: s, End: i}
+		ps := s
+		if colon != -1 {
+			sel.Name = p.src[s:colon]
+			ps = colon + 1
+		}
+		var err error
+		sel.Path, err = p.path(ps, i)
+		m.Selectors = append(m.Selectors, sel)
+		colon, modifier, s = -1, false, i+1
+		return err
+	}
+	for i++; i < end; i++ {
+		switch p.src[i] {
+		case '\\':
+			i++
+		case '@':
+			if !modifier && (p.src[i-1] == '.' || p.src[i-1] == '|') {
+				modifier = true
+			}
+		case ':':
+			if !modifier && colon == -1 && depth == 1 {
+				colon = i
+			}
+		case ',':
+			if depth == 1 {
+				if err := push(i); err != nil {
+					return nil, 0, err
+				}
+			}
+		case '"':
+			i = skipString(p.src[:end], i)
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+			if depth == 0 {
+				if err := push(i); err != nil {
+					return nil, 0, err
+				}
+				m.End = i + 1
+				return m, i + 1, p.expectSep(i+1, end)
+			}
+		}
+	}
+	return nil, 0, p.errorf(m.Start, "unterminated multipath")
+}
+
+// expectSep returns an error when i isn't the end of the path or a
+// separator.
+func (p *parser) expectSep(i, end int) error {
+	if i < end && p.src[i] != '.' && p.src[i] != '|' {
+		return p.errorf(i, "expected '.' or '|'")
+	}
+	return nil
+}
+
+// skipString returns the position of the closing quote of the string that
+// starts at s[i], or len(s) when there's none.
+func skipString(s string, i int) int {
+	for i++; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+		} else if s[i] == '"' {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// squash returns the json value at the start of s, which starts with a '[',
+// '{', '(', or '"', or all of s when the value doesn't end.
+func squash(s string) string {
+	if s[0] == '"' {
+		i := skipString(s, 0)
+		if i == len(s) {
+			return s
+		}
+		return s[:i+1]
+	}
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			i = skipString(s, i)
+		case '{', '[', '(':
+			depth++
+		case '}', ']', ')':
+			depth--
+			if depth == 0 {
+				return s[:i+1]
+			}
+		}
+	}
+	return s
+}
+
+// trimSpan returns the span of s[start:end] without the space around it.
+func trimSpan(s string, start, end int) (int, int) {
+	for start < end && s[start] <= ' ' {
+		start++
+	}
+	for end > start && s[end-1] <= ' ' {
+		end--
+	}
+	return start, end
+}
@@ -0,0 +1,419 @@
+package syntax
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func assert(t testing.TB, cond bool) {
+	t.Helper()
+	if !cond {
+		t.Fatal("assert failed")
+	}
+}
+
+const testJSON = `{
+  "name": {"first": "Tom", "last": "Anderson"},
+  "age":37,
+  "ages":[37,44],
+  "children": ["Sara","Alex","Jack"],
+  "fav.movie": "Deer Hunter",
+  "a:b": 1,
+  "#": "hash",
+  "01": "zero one",
+  "friends": [
+    {"first": "Dale", "last": "Murphy", "age": 44, "nets": ["ig", "fb", "tw"]},
+    {"first": "Roger", "last": "Craig", "age": 68, "nets": ["fb", "tw"]},
+    {"first": "Jane", "last": "Murphy", "age": 47, "nets": ["ig", "tw"]}
+  ],
+  "name": {"middle": "Lee"},
+  "q": [[1,2],[3,4]],
+  "m": [{"t": [3,1]}, {"t": [9,8]}]
+}`
+
+var testPaths = []string{
+	"",
+	"name.last",
+	"name.first|@reverse",
+	"age",
+	"children",
+	"children.#",
+	"children.1",
+	"children.01",
+	"01",
+	`\#`,
+	"child*.2",
+	"c?ildren.0",
+	`fav\.movie`,
+	`a\:b`,
+	"~NAME.~First",
+	"~child*.0",
+	"friends.#.first",
+	"friends.#.nets.#",
+	"friends.#.nets.0",
+	"friends.#.first|0",
+	"friends.#.first|#",
+	"friends.1.last",
+	`friends.#(last=="Murphy").first`,
+	`friends.#(last = "Murphy")#.first`,
+	`friends.#(last=="Murphy")#.first|@reverse`,
+	`friends.#(last=="Murphy")#|#`,
+	"friends.#(age>45)#.last",
+	"friends.#(age<=47)#.age",
+	`friends.#(first%"D*").last`,
+	`friends.#(first!%"D*").last`,
+	`friends.#(first!="Dale")#.first`,
+	`friends.#(nets.#(=="fb"))#.first`,
+	`friends.#[last=="Murphy"].first`,
+	"friends.#(nets)#.first",
+	`children.#(!%"*a*")#`,
+	"children|@reverse",
+	"children.@reverse.0",
+	"children|@reverse|0",
+	"@reverse",
+	`@pretty:{"indent":"    "}`,
+	"friends.#.@reverse",
+	"q.#.@reverse.0",
+	"q.#.@reverse|0",
+	"q.#(0>2)#.@reverse.0",
+	"m.#.t.@reverse",
+	"m.#.t.@reverse.1",
+	"m.#(t)#.t.@reverse",
+	"m.#.!1",
+	"m.#.t.!1",
+	"m.#(t)#.!1",
+	"m.#.[t.0,!2]",
+	"m.#.t.[0,1].@reverse",
+	"q.#.@this.#",
+	"m.#(t).!1",
+	"m.#(t).t.@reverse.0",
+	"q.#(0>2).@reverse.0",
+	"q.#(0>2)#.@reverse|0",
+	"m.#.t.#.!1",
+	"m.#.!1|@reverse",
+	"m.#.!true.x",
+	"..t.#.!1",
+	"..t.@reverse",
+	"name.@flatten.!true",
+	"..first.!true",
+	"..first.!true|@reverse",
+	"{first,a}.!1",
+	"[a].!1",
+	`@this.!"x"`,
+	"!true.!false",
+	"m.#.t|@reverse",
+	"friends.0.@keys",
+	`@dig:first`,
+	"[name.first,age,missing]",
+	"{name.first,age,\"the_age\":age,b:!true}",
+	"{name.first,friends.#(age>45)#.last}",
+	"[age,[name.first]]|@ugly",
+	"name.[first,last]",
+	"missing.[first,last]",
+	"missing|@this",
+	"missing.a|@this",
+	"missing.#.a",
+	"missing.@reverse",
+	"missing.[0]",
+	"#.[0,3]",
+	"name.middle",
+	"a*.#(==44)",
+	"a*.#(==45)#|#",
+	"name|{first}.first",
+	"!true",
+	`!"hello"`,
+	"!123.45",
+	`!{"a":[1,2]}.a.1`,
+	"name|!null",
+	"..#",
+	"..0",
+	"..#.a",
+	"..first",
+	"..~FIRST",
+	"..fir*",
+	`..\#`,
+	"..nets.0",
+	"..nets.#",
+	"..nets|#",
+	"..nets.@reverse",
+	"..nets.0.@reverse",
+	"..friends.#(age>45)#.first",
+	"..friends.#.nets.#(==\"tw\")#",
+	"..friends.#.[first,age]",
+	"..name.[first,last]",
+	"..friends..first",
+	"friends..first|@reverse",
+	"friends.#..first",
+	"friends.#(age>45)#..first",
+	"name..first",
+	"@this..first",
+	"[..first,..missing]",
+	"..missing",
+	"..a",
+	"..a|@reverse",
+	"..c.@this",
+	"..b|#",
+	"..#..a",
+	"..0..a",
+	"friends.#(first==\"Jane\")..nets",
+}
+
+const testLines = `{"a":1}
+{"a":2,"b":true}
+{"c":3}`
+
+// genJSON is searched by the generated paths, along with testJSON.
+const genJSON = `{
+  "friends": [
+    {"first": "Dale", "age": 44, "nets": ["ig", "fb"], "x": 3},
+    {"first": "Roger", "age": 68, "nets": [], "x": 9}
+  ],
+  "arr": [{"x": 1, "y": [1, 2]}, {"x": 7, "y": {"z": 1}}, 3, "s"],
+  "name": {"first": "Tom", "last": "Anderson"},
+  "empty": [],
+  "obj": {},
+  "a.b": [{"x": 1}, {"x": 8, "first": "Ann"}]
+}`
+
+var genKeys = []string{"friends", "first", "age", "nets", "x", "y", "z",
+	"arr", "name", "empty", "obj", `a\.b`, "missing", "0", "1", "5", "f*"}
+
+var genHashes = []string{"#", "#(age>99)#", "#(age>45)#", "#(age>45)",
+	"#(x>5)#", "#(x>5)", "#(nets)#", `#(=="fb")#`, "#(age>99)", "#(y)#"}
+
+var genMods = []string{"@reverse", "@this", "@flatten", "@keys", "@values",
+	"@ugly", "@valid", "@join"}
+
+// genPath returns a random path of up to n components.
+func genPath(r *rand.Rand, n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			if r.Intn(3) == 0 {
+				sb.WriteByte('|')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		switch k := r.Intn(20); {
+		case k < 8:
+			sb.WriteString(genKeys[r.Intn(len(genKeys))])
+		case k < 12:
+			sb.WriteString(genHashes[r.Intn(len(genHashes))])
+		case k < 14:
+			sb.WriteString(genMods[r.Intn(len(genMods))])
+		case k < 15:
+			sb.WriteString([]string{"!true", "!1", `!"s"`}[r.Intn(3)])
+		case k < 17 && n > 1:
+			open, close := "[", "]"
+			if r.Intn(2) == 0 {
+				open, close = "{", "}"
+			}
+			sb.WriteString(open)
+			for j, m := 0, 1+r.Intn(2); j < m; j++ {
+				if j > 0 {
+					sb.WriteByte(',')
+				}
+				sb.WriteString(genPath(r, 1+r.Intn(2)))
+			}
+			sb.WriteString(close)
+		case k < 18:
+			sb.WriteString("..")
+			sb.WriteString(genKeys[r.Intn(len(genKeys))])
+		default:
+			sb.WriteString(genKeys[r.Intn(len(genKeys))])
+		}
+	}
+	return sb.String()
+}
+
+// genPaths returns n random paths that parse, with a fixed seed.
+func genPaths(n int) []string {
+	r := rand.New(rand.NewSource(1))
+	seen := make(map[string]bool)
+	var paths []string
+	for len(paths) < n {
+		path := genPath(r, 1+r.Intn(6))
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		if _, err := Parse(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// TestRoundTrip checks the listed paths and generated paths, which must
+// have the same results as gjson.
+func TestRoundTrip(t *testing.T) {
+	for _, path := range append(testPaths, genPaths(20000)...) {
+		p, err := Parse(path)
+		if err != nil {
+			t.Fatalf("%q: %v", path, err)
+		}
+		formatted := Format(p)
+		p2, err := Parse(formatted)
+		if err != nil {
+			t.Fatalf("%q: %q: %v", path, formatted, err)
+		}
+		if Format(p2) != formatted || !Equal(p, p2) {
+			t.Fatalf("%q: %q is not the same tree", path, formatted)
+		}
+		for _, json := range []string{testJSON, testLines, genJSON} {
+			exp := gjson.Get(json, path)
+			for _, res := range []gjson.Result{
+				Eval(json, p), gjson.Get(json, formatted),
+				Explain(json, path).Result,
+			} {
+				if res.Raw != exp.Raw || res.Type != exp.Type {
+					t.Fatalf("%q: expected %q, got %q", path, exp.Raw, res.Raw)
+				}
+			}
+		}
+
+		// a folded key is evaluated like gjson with the '~' prefix
+		p, err = ParseMode(path, CaseInsensitivePrefix)
+		if err != nil {
+			t.Fatalf("%q: %v", path, err)
+		}
+		formatted = Format(p)
+		p2, err = ParseMode(formatted, CaseInsensitivePrefix)
+		if err != nil || !Equal(p, p2) {
+			t.Fatalf("%q: %q is not the same tree", path, formatted)
+		}
+		for _, json := range []string{testJSON, testLines, genJSON} {
+			exp, _ := gjson.GetWithOptions(json, path, prefixOptions)
+			if res := Eval(json, p); res.Raw != exp.Raw ||
+				res.Type != exp.Type {
+				t.Fatalf("%q: expected %q, got %q", path, exp.Raw, res.Raw)
+			}
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	path := `friends.#( last == "Murphy" )#.~first|@case:upper.x|[a,"b":b]`
+	p, err := ParseMode(path, CaseInsensitivePrefix)
+	assert(t, err == nil && len(p.Steps) == 5)
+	key := p.Steps[0].Component.(*Key)
+	assert(t, key.Name == "friends" && key.Start == 0 && key.End == 7)
+	q := p.Steps[1].Component.(*Query)
+	assert(t, p.Steps[1].Sep == Dot && q.All && q.Op == "==")
+	assert(t, path[q.Start:q.End] == `#( last == "Murphy" )#`)
+	assert(t, path[q.ValueStart:q.ValueEnd] == `"Murphy"`)
+	assert(t, q.Value == `"Murphy"`)
+	qkey := q.Path.Steps[0].Component.(*Key)
+	assert(t, path[qkey.Start:qkey.End] == "last")
+	key = p.Steps[2].Component.(*Key)
+	assert(t, key.Fold && key.Name == "first" && path[key.Start:key.End] ==
+		"~first")
+	m := p.Steps[3].Component.(*Modifier)
+	assert(t, p.Steps[3].Sep == Pipe && m.Name == "case" && m.HasArg)
+	assert(t, m.Arg == "upper.x" && path[m.ArgStart:m.ArgEnd] == "upper.x")
+	mp := p.Steps[4].Component.(*Multipath)
+	assert(t, !mp.Object && len(mp.Selectors) == 2)
+	assert(t, mp.Selectors[1].Name == `"b"`)
+	assert(t, path[mp.Selectors[1].Start:mp.Selectors[1].End] == `"b":b`)
+	assert(t, Format(p) ==
+		`friends.#(last=="Murphy")#.~first|@case:upper.x|[a,"b":b]`)
+
+	p = MustParse(`a\.b.c*\?.0.10.01.#.#(b)|!true`)
+	assert(t, p.Steps[0].Component.(*Key).Name == "a.b")
+	key = p.Steps[1].Component.(*Key)
+	assert(t, key.Name == "c*?" && key.Wild)
+	assert(t, p.Steps[2].Component.(*Index).N == 0)
+	assert(t, p.Steps[3].Component.(*Index).N == 10)
+	assert(t, p.Steps[4].Component.(*Key).Name == "01")
+	assert(t, p.Steps[5].Component.(*Hash).End == 19)
+	q = p.Steps[6].Component.(*Query)
+	assert(t, q.Op == "" && !q.All && q.Path.Steps[0].Component.(*Key).Name ==
+		"b")
+	assert(t, p.Steps[7].Component.(*Literal).Value == "true")
+
+	// a literal is a key after a dot
+	p = MustParse("a.!true")
+	assert(t, p.Steps[1].Component.(*Key).Name == "!true")
+	assert(t, Format(p) == `a.\!true`)
+	p = MustParse("!x")
+	assert(t, p.Steps[0].Component.(*Key).Name == "!x")
+
+	// a recursive descent includes the '.' separator before it
+	p, _ = ParseMode("a..~b*.c|..d", CaseInsensitivePrefix)
+	d := p.Steps[1].Component.(*Descent)
+	assert(t, p.Steps[1].Sep == Dot && d.Start == 1 && d.End == 6)
+	assert(t, d.Key.Name == "b*" && d.Key.Fold && d.Key.Wild)
+	d = p.Steps[3].Component.(*Descent)
+	assert(t, p.Steps[3].Sep == Pipe && d.Start == 9 && d.Key.Name == "d")
+	assert(t, Format(p) == "a..~b*.c|..d")
+
+	// without the mode, the '~' is part of the key, like gjson.Get
+	p = MustParse("~first..~b")
+	key = p.Steps[0].Component.(*Key)
+	assert(t, !key.Fold && key.Name == "~first")
+	d = p.Steps[1].Component.(*Descent)
+	assert(t, !d.Key.Fold && d.Key.Name == "~b")
+	assert(t, Format(p) == `\~first..\~b`)
+	assert(t, !p.Lines && MustParse("..0").Lines)
+	assert(t, Format(&Path{Steps: []Step{
+		{NoSep, &Descent{Key: &Key{Name: "0a"}}},
+	}}) == `..\0a`)
+
+	// keys that look like other components are escaped
+	p = &Path{Steps: []Step{
+		{NoSep, &Key{Name: "0"}},
+		{Dot, &Key{Name: "a.b|c"}},
+		{Dot, &Key{Name: "#"}},
+		{Dot, &Key{Name: "@x"}},
+		{Dot, &Key{Name: "~"}},
+		{Dot, &Key{Name: "x:y"}},
+		{Dot, &Key{Name: "a*", Wild: true}},
+	}}
+	assert(t, Format(p) == `\0.a\.b\|c.\#.\@x.\~.x\:y.a*`)
+	assert(t, Equal(p, MustParse(Format(p))))
+
+	assert(t, !Equal(p, MustParse(`\0.a\.b\|c.\#.\@x.\~.x\:y.a\*`)))
+	assert(t, !Equal(MustParse("a.#(b==1)"), MustParse("a.#(b==2)")))
+	assert(t, !Equal(MustParse("[a,b]"), MustParse("{a,b}")))
+	assert(t, Equal(MustParse("a|[b,c]"), MustParse("a|[b,c]")))
+
+	var nodes []string
+	Inspect(MustParse(`a.#(b.c==1)|[d,e]`), func(n Node) bool {
+		nodes = append(nodes, reflect.TypeOf(n).Elem().Name())
+		return true
+	})
+	assert(t, reflect.DeepEqual(nodes, []string{"Path", "Key", "Query", "Path",
+		"Key", "Key", "Multipath", "Selector", "Path", "Key", "Selector",
+		"Path", "Key"}))
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		path string
+		pos  int
+	}{
+		{"a.#(b==1", 2},
+		{"a.#(b==1)x", 9},
+		{"a|[b,c", 2},
+		{"[b,c]x", 5},
+		{"a.#(b!x)", 5},
+		{`@pretty:{"a":1}x`, 15},
+		{"[a.#(b]", 0},
+		{"....a", 2},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.path)
+		var serr *Error
+		if !errors.As(err, &serr) || serr.Pos != tt.pos {
+			t.Fatalf("%q: expected error at %d, got %v", tt.path, tt.pos, err)
+		}
+	}
+	defer func() { assert(t, recover() != nil) }()
+	MustParse("a.#(")
+}